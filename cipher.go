@@ -0,0 +1,268 @@
+package pasta
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// DefaultNonce is the nonce used by Encrypt and Decrypt.
+const DefaultNonce = uint64(123456789)
+
+// FieldStreamCipher is a stream cipher over F_p: the ciphertext is the
+// plaintext plus a keystream, element-wise mod the plaintext modulus.
+type FieldStreamCipher interface {
+	Keystream(nonce uint64, blockCounter uint64) []uint64
+	EncryptWithNonce(plaintext []uint64, nonce uint64) []uint64
+	DecryptWithNonce(ciphertext []uint64, nonce uint64) []uint64
+	Params() Params
+	PlainModulus() uint64
+}
+
+// CipherFactory builds a cipher from its key, modulus and parameters.
+type CipherFactory func(secretKey []uint64, modulus uint64, params Params) FieldStreamCipher
+
+// CipherConfig is the serialized form of a cipher setup, see
+// ParseCipherConfig. When
+// Params is nil the registered defaults for Name are used.
+type CipherConfig struct {
+	Name    string  `json:"name"`
+	Modulus uint64  `json:"modulus"`
+	Params  *Params `json:"params,omitempty"`
+}
+
+type registryEntry struct {
+	params  Params
+	factory CipherFactory
+
+	// rejects params the cipher cannot run with
+	validate func(Params) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]registryEntry)
+)
+
+func init() {
+	newPasta := func(secretKey []uint64, modulus uint64, params Params) FieldStreamCipher {
+		pasta := NewPasta(secretKey, modulus, params)
+		return &pasta
+	}
+	newHera := func(secretKey []uint64, modulus uint64, params Params) FieldStreamCipher {
+		hera := NewHera(secretKey, modulus, params)
+		return &hera
+	}
+	newMasta := func(secretKey []uint64, modulus uint64, params Params) FieldStreamCipher {
+		masta := NewMasta(secretKey, modulus, params)
+		return &masta
+	}
+	newRubato := func(sigma float64) CipherFactory {
+		return func(secretKey []uint64, modulus uint64, params Params) FieldStreamCipher {
+			rubato := NewRubato(secretKey, modulus, RubatoParams{params, sigma})
+			return &rubato
+		}
+	}
+
+	// HERA has a fixed 16-element state, MASTA and Rubato a state of the
+	// key size, Rubato's a v x v matrix
+	validateHera := func(params Params) error {
+		if params.SecretKeySize != HeraT {
+			return fmt.Errorf("invalid secret key size %d, expected %d", params.SecretKeySize, HeraT)
+		}
+		return validateBlock(params, HeraT)
+	}
+	validateMasta := func(params Params) error {
+		if params.SecretKeySize == 0 {
+			return fmt.Errorf("invalid secret key size 0")
+		}
+		return validateBlock(params, params.SecretKeySize)
+	}
+	validateRubato := func(params Params) error {
+		v := uint64(math.Sqrt(float64(params.SecretKeySize)))
+		if _, ok := rubatoMix[int(v)]; !ok || v*v != params.SecretKeySize {
+			return fmt.Errorf("invalid secret key size %d, expected 16, 36 or 64", params.SecretKeySize)
+		}
+		return validateBlock(params, params.SecretKeySize)
+	}
+
+	registerCipher("pasta3", Pasta3, newPasta, validatePastaParams)
+	registerCipher("pasta4", Pasta4, newPasta, validatePastaParams)
+	registerCipher("hera4", Hera4, newHera, validateHera)
+	registerCipher("hera5", Hera5, newHera, validateHera)
+	registerCipher("masta4", Masta4, newMasta, validateMasta)
+	registerCipher("masta5", Masta5, newMasta, validateMasta)
+	registerCipher("rubato80s", Rubato80S.Params, newRubato(Rubato80S.Sigma), validateRubato)
+	registerCipher("rubato80m", Rubato80M.Params, newRubato(Rubato80M.Sigma), validateRubato)
+	registerCipher("rubato80l", Rubato80L.Params, newRubato(Rubato80L.Sigma), validateRubato)
+	registerCipher("rubato128s", Rubato128S.Params, newRubato(Rubato128S.Sigma), validateRubato)
+	registerCipher("rubato128m", Rubato128M.Params, newRubato(Rubato128M.Sigma), validateRubato)
+	registerCipher("rubato128l", Rubato128L.Params, newRubato(Rubato128L.Sigma), validateRubato)
+}
+
+// RegisterCipher makes a cipher constructible by name, replacing any
+// previous registration under the same name. NewCipherFromConfig only
+// accepts a prime modulus, 1 to 65536 rounds and params whose block of
+// 0 < PlainSize = CipherSize elements fits in the secret key.
+func RegisterCipher(name string, defaults Params, factory CipherFactory) {
+	registerCipher(name, defaults, factory, func(params Params) error {
+		return validateBlock(params, params.SecretKeySize)
+	})
+}
+
+func registerCipher(name string, defaults Params, factory CipherFactory, validate func(Params) error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry[name] = registryEntry{defaults, factory, validate}
+}
+
+// maxRounds bounds the rounds of every cipher, so that a config or file
+// cannot request an arbitrary amount of work.
+const maxRounds = 1 << 16
+
+// validatePastaParams requires an even secret key of two halves of t
+// elements, a block of 0 < PlainSize = CipherSize <= t elements and 1 to
+// maxRounds rounds.
+func validatePastaParams(params Params) error {
+	if params.SecretKeySize < 2 || params.SecretKeySize%2 != 0 {
+		return fmt.Errorf("invalid secret key size %d", params.SecretKeySize)
+	}
+	if err := validateRounds(params); err != nil {
+		return err
+	}
+	return validateBlock(params, params.SecretKeySize/2)
+}
+
+func validateRounds(params Params) error {
+	if params.Rounds == 0 || params.Rounds > maxRounds {
+		return fmt.Errorf("invalid number of rounds %d, expected 1 to %d", params.Rounds, maxRounds)
+	}
+	return nil
+}
+
+func validateBlock(params Params, stateSize uint64) error {
+	if params.PlainSize == 0 || params.PlainSize > stateSize {
+		return fmt.Errorf("invalid plain size %d, expected 1 to %d", params.PlainSize, stateSize)
+	}
+	if params.CipherSize != params.PlainSize {
+		return fmt.Errorf("cipher size %d differs from plain size %d",
+			params.CipherSize, params.PlainSize)
+	}
+	return nil
+}
+
+// Ciphers returns the registered cipher names in sorted order.
+func Ciphers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// DefaultParams returns the parameters registered under name.
+func DefaultParams(name string) (Params, error) {
+	registryMu.RLock()
+	entry, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return Params{}, fmt.Errorf("unknown cipher %q", name)
+	}
+	return entry.params, nil
+}
+
+// NewCipher builds the cipher registered under name with its default params.
+func NewCipher(name string, secretKey []uint64, modulus uint64) (FieldStreamCipher, error) {
+	return NewCipherFromConfig(CipherConfig{name, modulus, nil}, secretKey)
+}
+
+// ParseCipherConfig decodes a JSON-encoded CipherConfig, e.g.
+// {"name": "pasta4", "modulus": 65537}.
+func ParseCipherConfig(data []byte) (CipherConfig, error) {
+	var config CipherConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return CipherConfig{}, fmt.Errorf("invalid cipher config: %w", err)
+	}
+	return config, nil
+}
+
+// NewCipherFromConfig builds the cipher described by config. The modulus
+// must be prime and the params valid for the cipher.
+func NewCipherFromConfig(config CipherConfig, secretKey []uint64) (FieldStreamCipher, error) {
+	registryMu.RLock()
+	entry, ok := registry[config.Name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown cipher %q", config.Name)
+	}
+
+	params := entry.params
+	if config.Params != nil {
+		params = *config.Params
+	}
+
+	if !new(big.Int).SetUint64(config.Modulus).ProbablyPrime(20) {
+		return nil, fmt.Errorf("invalid modulus %d, expected a prime", config.Modulus)
+	}
+	if err := validateRounds(params); err != nil {
+		return nil, fmt.Errorf("%s: %w", config.Name, err)
+	}
+	if err := entry.validate(params); err != nil {
+		return nil, fmt.Errorf("%s: %w", config.Name, err)
+	}
+	if uint64(len(secretKey)) != params.SecretKeySize {
+		return nil, fmt.Errorf("%s: secret key has %d elements, expected %d",
+			config.Name, len(secretKey), params.SecretKeySize)
+	}
+
+	return entry.factory(secretKey, config.Modulus, params), nil
+}
+
+// encryptBlocks adds the keystream of consecutive blocks to plaintext.
+func encryptBlocks[T Word](plaintext []T, nonce, blockSize, modulus uint64,
+	keystream func(nonce, blockCounter uint64) []T) []T {
+	size := uint64(len(plaintext))
+	numBlock := (size + blockSize - 1) / blockSize
+
+	ciphertext := make([]T, size)
+	copy(ciphertext, plaintext)
+
+	for b := uint64(0); b < numBlock; b++ {
+		ks := keystream(nonce, b)
+		for i := b * blockSize; i < (b+1)*blockSize && i < size; i++ {
+			ciphertext[i] = T(addMod(uint64(ciphertext[i])%modulus, uint64(ks[i-b*blockSize]), modulus))
+		}
+	}
+
+	return ciphertext
+}
+
+// decryptBlocks subtracts the keystream of consecutive blocks from ciphertext.
+func decryptBlocks[T Word](ciphertext []T, nonce, blockSize, modulus uint64,
+	keystream func(nonce, blockCounter uint64) []T) []T {
+	size := uint64(len(ciphertext))
+	numBlock := (size + blockSize - 1) / blockSize
+
+	plaintext := make([]T, size)
+	copy(plaintext, ciphertext)
+
+	for b := uint64(0); b < numBlock; b++ {
+		ks := keystream(nonce, b)
+		for i := b * blockSize; i < (b+1)*blockSize && i < size; i++ {
+			plaintext[i] = T(subMod(uint64(plaintext[i])%modulus, uint64(ks[i-b*blockSize]), modulus))
+		}
+	}
+
+	return plaintext
+}
@@ -0,0 +1,144 @@
+package pasta
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+var (
+	_ FieldStreamCipher = (*Pasta)(nil)
+	_ FieldStreamCipher = (*Hera)(nil)
+	_ FieldStreamCipher = (*Masta)(nil)
+	_ FieldStreamCipher = (*Rubato)(nil)
+)
+
+func TestRegistryRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	modulus := uint64(65929217)
+
+	for _, name := range Ciphers() {
+		params, err := DefaultParams(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		secretKey := randomVector(rng, int(params.SecretKeySize), modulus)
+		cipher, err := NewCipher(name, secretKey, modulus)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if cipher.Params() != params || cipher.PlainModulus() != modulus {
+			t.Errorf("%s: unexpected params %v or modulus %d", name, cipher.Params(), cipher.PlainModulus())
+		}
+
+		plaintext := randomVector(rng, 2*int(params.PlainSize)+1, modulus)
+		ciphertext := cipher.EncryptWithNonce(plaintext, 42)
+		decrypted := cipher.DecryptWithNonce(ciphertext, 42)
+
+		if strings.HasPrefix(name, "rubato") {
+			// approximate decryption, checked in rubato_test.go
+			continue
+		}
+		if !equalSlices(decrypted, plaintext) {
+			t.Errorf("%s: different plaintexts. decrypted(%d), plaintext(%d)",
+				name, len(decrypted), len(plaintext))
+		}
+		if equalSlices(cipher.EncryptWithNonce(plaintext, 43), ciphertext) {
+			t.Errorf("%s: ciphertext does not depend on the nonce", name)
+		}
+	}
+}
+
+func TestRegistryAbove63Bits(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	modulus := uint64(18446744073709551557)
+
+	for _, name := range Ciphers() {
+		params, _ := DefaultParams(name)
+		cipher, err := NewCipher(name, randomVector(rng, int(params.SecretKeySize), modulus), modulus)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		// one block, built by hand with the carry-safe addition
+		plaintext := randomVector(rng, int(params.PlainSize), modulus)
+		ks := cipher.Keystream(42, 0)
+		ciphertext := make([]uint64, len(plaintext))
+		for i := range plaintext {
+			ciphertext[i] = addMod(plaintext[i], ks[i], modulus)
+		}
+
+		if !equalSlices(cipher.DecryptWithNonce(ciphertext, 42), plaintext) {
+			t.Errorf("%s: Decrypt is wrong above 2^63", name)
+		}
+		if !strings.HasPrefix(name, "rubato") && !equalSlices(cipher.EncryptWithNonce(plaintext, 42), ciphertext) {
+			t.Errorf("%s: Encrypt is wrong above 2^63", name)
+		}
+	}
+}
+
+func TestNewCipherFromConfig(t *testing.T) {
+	config, err := ParseCipherConfig([]byte(`{"name": "pasta3", "modulus": 65537,
+		"params": {"secret_key_size": 64, "plain_size": 32, "cipher_size": 32, "rounds": 3}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secretKey := randomVector(rand.New(rand.NewSource(2)), 64, 65537)
+	cipher, err := NewCipherFromConfig(config, secretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Params{64, 32, 32, 3}
+	if cipher.Params() != expected {
+		t.Errorf("params %v, expected %v", cipher.Params(), expected)
+	}
+	if len(cipher.Keystream(DefaultNonce, 0)) != 32 {
+		t.Errorf("keystream has %d elements, expected 32", len(cipher.Keystream(DefaultNonce, 0)))
+	}
+}
+
+func TestNewCipherErrors(t *testing.T) {
+	if _, err := NewCipher("pasta5", make([]uint64, 256), 65537); err == nil {
+		t.Errorf("expected error for unknown cipher")
+	}
+	if _, err := NewCipher("pasta3", make([]uint64, 64), 65537); err == nil {
+		t.Errorf("expected error for wrong key size")
+	}
+	for _, modulus := range []uint64{0, 1, 65535, 18446744073709551615} {
+		if _, err := NewCipher("pasta3", make([]uint64, 256), modulus); err == nil {
+			t.Errorf("modulus %d: expected an error", modulus)
+		}
+	}
+	if _, err := ParseCipherConfig([]byte(`{"name": `)); err == nil {
+		t.Errorf("expected error for malformed config")
+	}
+
+	for _, c := range []struct {
+		name   string
+		params Params
+	}{
+		{"pasta3", Params{64, 128, 128, 3}},
+		{"pasta3", Params{64, 0, 0, 3}},
+		{"pasta3", Params{63, 16, 16, 3}},
+		{"pasta4", Params{64, 32, 16, 4}},
+		{"hera4", Params{16, 32, 32, 4}},
+		{"hera4", Params{32, 16, 16, 4}},
+		{"masta4", Params{64, 65, 65, 4}},
+		{"rubato80s", Params{25, 12, 12, 2}},
+		{"rubato80s", Params{16, 0, 0, 2}},
+		{"pasta4", Params{64, 32, 32, 0}},
+		{"pasta4", Params{64, 32, 32, 1<<16 + 1}},
+		{"hera5", Params{16, 16, 16, 0}},
+		{"masta4", Params{64, 64, 64, 0}},
+		{"rubato128l", Params{64, 60, 60, 0}},
+	} {
+		params := c.params
+		config := CipherConfig{c.name, 65537, &params}
+		if _, err := NewCipherFromConfig(config, make([]uint64, params.SecretKeySize)); err == nil {
+			t.Errorf("%s: expected error for params %v", c.name, params)
+		}
+	}
+}
@@ -0,0 +1,579 @@
+// Command pasta generates PASTA keys and encrypts and decrypts vectors of
+// field elements.
+//
+//	pasta keygen  [-params pasta3|pasta4] [-modulus p] [-out key]
+//	pasta encrypt -key key [-nonce n] [-format dec|hex|bin] [-in file] [-out file]
+//	pasta decrypt -key key [-format dec|hex|bin] [-in file] [-out file]
+//	pasta inspect [file]
+//	pasta vectors [-params pasta3|pasta4] [-modulus p] [-n count] [-nonce n] [-block i] [-seed s] [-out file]
+//	pasta vectors -verify file
+//	pasta trace -key key [-nonce n] [-block i] [-out file] [-diff file]
+//	pasta randtest [-params pasta3|pasta4] [-modulus p] [-blocks n] [-nonce n] [-seed s] [-alpha a]
+//	pasta matrices [-params pasta3|pasta4] [-modulus p] [-nonce n] [-block i] [-blocks n]
+//	pasta selftest
+//
+// Keys and ciphertexts use the binary key and container formats of the
+// pasta package. Plaintexts are read and written as decimal, hexadecimal or
+// 8-byte big-endian elements. A missing -in or -out means stdin or stdout.
+// Without -nonce, encrypt draws a random 64-bit nonce, which is stored in
+// the container.
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	mathrand "math/rand"
+	"os"
+	"sort"
+
+	"github.com/fedejinich/pasta-go"
+	"github.com/fedejinich/pasta-go/internal/randtest"
+)
+
+type command struct {
+	run   func(args []string, stdin io.Reader, stdout io.Writer) error
+	usage string
+}
+
+var commands = map[string]command{
+	"keygen":   {keygen, "generate a secret key"},
+	"encrypt":  {encrypt, "encrypt field elements into a container"},
+	"decrypt":  {decrypt, "decrypt a container into field elements"},
+	"inspect":  {inspect, "describe a key or container file"},
+	"vectors":  {vectors, "generate or verify known-answer test vectors"},
+	"trace":    {trace, "trace the keystream round by round, or diff a trace"},
+	"randtest": {randomnessTests, "run statistical tests on keystream output"},
+	"matrices": {matrices, "check that the generated affine matrices are invertible"},
+	"selftest": {selfTest, "check the built-in known answers"},
+}
+
+// presets selectable with -params
+var presets = map[string]pasta.Params{
+	"pasta3": pasta.Pasta3,
+	"pasta4": pasta.Pasta4,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "pasta: unknown command %q\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+
+	if err := cmd.run(args[1:], stdin, stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		fmt.Fprintf(stderr, "pasta %s: %v\n", args[0], err)
+		return 1
+	}
+
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: pasta <command> [flags]")
+	fmt.Fprintln(w)
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-8s %s\n", name, commands[name].usage)
+	}
+}
+
+func keygen(args []string, _ io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	preset := fs.String("params", "pasta3", "parameter preset (pasta3, pasta4)")
+	modulus := fs.Uint64("modulus", 65537, "plaintext prime modulus")
+	out := fs.String("out", "", "key file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	params, ok := presets[*preset]
+	if !ok {
+		return fmt.Errorf("unknown params preset %q", *preset)
+	}
+	if err := checkModulus(*modulus); err != nil {
+		return err
+	}
+
+	key, err := pasta.GenerateKey(rand.Reader, *modulus, params)
+	if err != nil {
+		return err
+	}
+	defer key.Destroy()
+
+	data, err := key.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*out, stdout, data)
+}
+
+func encrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	keyFile := fs.String("key", "", "key file")
+	nonce := fs.Uint64("nonce", 0, "nonce, never reused under one key (default random)")
+	format := fs.String("format", "dec", "plaintext format (dec, hex, bin)")
+	in := fs.String("in", "", "plaintext file (default stdin)")
+	out := fs.String("out", "", "container file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	key, err := readKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	defer key.Destroy()
+	elementFormat, err := pasta.ParseElementFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	r, err := openInput(*in, stdin)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	plaintext, err := pasta.ReadElements(r, elementFormat)
+	if err != nil {
+		return err
+	}
+	for i, e := range plaintext {
+		if e >= key.Modulus {
+			return fmt.Errorf("element %d: %d is not reduced mod %d", i, e, key.Modulus)
+		}
+	}
+
+	if !isFlagSet(fs, "nonce") {
+		if *nonce, err = randomNonce(); err != nil {
+			return err
+		}
+	}
+
+	data, err := key.Seal(plaintext, *nonce).MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*out, stdout, data)
+}
+
+func decrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	keyFile := fs.String("key", "", "key file")
+	format := fs.String("format", "dec", "plaintext format (dec, hex, bin)")
+	in := fs.String("in", "", "container file (default stdin)")
+	out := fs.String("out", "", "plaintext file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	key, err := readKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	defer key.Destroy()
+	elementFormat, err := pasta.ParseElementFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	data, err := readInput(*in, stdin)
+	if err != nil {
+		return err
+	}
+
+	var container pasta.Container
+	if err := container.UnmarshalBinary(data); err != nil {
+		return err
+	}
+
+	plaintext, err := key.Open(&container)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := pasta.WriteElements(&buf, plaintext, elementFormat); err != nil {
+		return err
+	}
+
+	return writeOutput(*out, stdout, buf.Bytes())
+}
+
+// inspect prints the header of a key or container, never key elements.
+func inspect(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errors.New("at most one file")
+	}
+
+	data, err := readInput(fs.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+
+	var key pasta.Key
+	keyErr := key.UnmarshalBinary(data)
+	if keyErr == nil {
+		fmt.Fprintln(stdout, "type:      key")
+		printHeader(stdout, key.Modulus, key.Params)
+		fmt.Fprintf(stdout, "elements:  %d\n", len(key.Elements))
+		return nil
+	}
+
+	var container pasta.Container
+	containerErr := container.UnmarshalBinary(data)
+	if containerErr == nil {
+		fmt.Fprintln(stdout, "type:      container")
+		printHeader(stdout, container.Modulus, container.Params)
+		fmt.Fprintf(stdout, "nonce:     %d\n", container.Nonce)
+		fmt.Fprintf(stdout, "elements:  %d\n", len(container.Ciphertext))
+		return nil
+	}
+
+	// report the error of the format the magic selected
+	if errors.Is(keyErr, pasta.ErrBadMagic) {
+		return containerErr
+	}
+	return keyErr
+}
+
+// vectors writes known-answer tests for random keys and plaintexts, or
+// replays a vector file with -verify.
+func vectors(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("vectors", flag.ContinueOnError)
+	preset := fs.String("params", "pasta3", "parameter preset (pasta3, pasta4)")
+	modulus := fs.Uint64("modulus", 65537, "plaintext prime modulus")
+	n := fs.Int("n", 1, "number of vectors")
+	nonce := fs.Uint64("nonce", pasta.DefaultNonce, "nonce")
+	block := fs.Uint64("block", 0, "block counter")
+	seed := fs.Int64("seed", 0, "seed of a reproducible generator (default crypto/rand)")
+	out := fs.String("out", "", "vector file (default stdout)")
+	verify := fs.String("verify", "", "vector file to verify instead of generating (- for stdin)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *verify != "" {
+		r, err := openInput(*verify, stdin)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		kats, err := pasta.ReadKATs(r)
+		if err != nil {
+			return err
+		}
+		for i, kat := range kats {
+			if err := kat.Verify(); err != nil {
+				return fmt.Errorf("vector %d: %w", i, err)
+			}
+		}
+
+		fmt.Fprintf(stdout, "%d vectors ok\n", len(kats))
+		return nil
+	}
+
+	params, ok := presets[*preset]
+	if !ok {
+		return fmt.Errorf("unknown params preset %q", *preset)
+	}
+	if err := checkModulus(*modulus); err != nil {
+		return err
+	}
+
+	var source io.Reader = rand.Reader
+	if *seed != 0 {
+		source = mathrand.New(mathrand.NewSource(*seed))
+	}
+
+	kats := make([]*pasta.KAT, *n)
+	for i := range kats {
+		kat, err := pasta.GenerateKAT(source, *modulus, params, *nonce, *block)
+		if err != nil {
+			return err
+		}
+		kats[i] = kat
+	}
+
+	var buf bytes.Buffer
+	if err := pasta.WriteKATs(&buf, kats); err != nil {
+		return err
+	}
+
+	return writeOutput(*out, stdout, buf.Bytes())
+}
+
+// trace writes the step-by-step trace of one keystream block, or with -diff
+// reports the first step where a trace file diverges from it.
+func trace(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("trace", flag.ContinueOnError)
+	keyFile := fs.String("key", "", "key file")
+	nonce := fs.Uint64("nonce", pasta.DefaultNonce, "nonce")
+	block := fs.Uint64("block", 0, "block counter")
+	out := fs.String("out", "", "trace file (default stdout)")
+	diff := fs.String("diff", "", "trace file to compare against (- for stdin)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	key, err := readKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	defer key.Destroy()
+
+	var tr pasta.Trace
+	util := pasta.NewUtil(key.Elements, key.Modulus, int(key.Params.Rounds))
+	util.SetTracer(tr.Record)
+	util.Keystream(*nonce, *block)
+
+	if *diff != "" {
+		r, err := openInput(*diff, stdin)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		other, err := pasta.ReadTrace(r)
+		if err != nil {
+			return err
+		}
+		if d := pasta.DiffTraces(tr, other); d != nil {
+			return fmt.Errorf("traces diverge at %v", d)
+		}
+
+		fmt.Fprintf(stdout, "%d steps match\n", len(tr))
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := pasta.WriteTrace(&buf, tr); err != nil {
+		return err
+	}
+
+	return writeOutput(*out, stdout, buf.Bytes())
+}
+
+// randomnessTests reports statistical tests on the keystream of a random
+// key, failing if any p-value is below -alpha.
+func randomnessTests(args []string, _ io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("randtest", flag.ContinueOnError)
+	preset := fs.String("params", "pasta3", "parameter preset (pasta3, pasta4)")
+	modulus := fs.Uint64("modulus", 65537, "plaintext prime modulus")
+	blocks := fs.Uint64("blocks", 1024, "number of keystream blocks")
+	nonce := fs.Uint64("nonce", pasta.DefaultNonce, "nonce")
+	seed := fs.Int64("seed", 0, "seed of a reproducible key (default crypto/rand)")
+	alpha := fs.Float64("alpha", randtest.DefaultAlpha, "significance level")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	params, ok := presets[*preset]
+	if !ok {
+		return fmt.Errorf("unknown params preset %q", *preset)
+	}
+	if err := checkModulus(*modulus); err != nil {
+		return err
+	}
+
+	var source io.Reader = rand.Reader
+	if *seed != 0 {
+		source = mathrand.New(mathrand.NewSource(*seed))
+	}
+	key, err := pasta.GenerateKey(source, *modulus, params)
+	if err != nil {
+		return err
+	}
+
+	util := pasta.NewUtil(key.Elements, key.Modulus, int(params.Rounds))
+	samples := make([]uint64, 0, *blocks*params.SecretKeySize/2)
+	for block := uint64(0); block < *blocks; block++ {
+		samples = append(samples, util.Keystream(*nonce, block)...)
+	}
+
+	fmt.Fprintf(stdout, "%s, p = %d, %d elements\n", *preset, *modulus, len(samples))
+
+	failed := 0
+	for _, r := range randtest.Suite(samples, *modulus) {
+		verdict := "ok"
+		if !r.Pass(*alpha) {
+			verdict = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(stdout, "%v  %s\n", r, verdict)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d tests below alpha = %g", failed, *alpha)
+	}
+	return nil
+}
+
+// matrices checks the invertibility of the matrices of the affine layers
+// of a range of blocks, failing if any is singular.
+func matrices(args []string, _ io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("matrices", flag.ContinueOnError)
+	preset := fs.String("params", "pasta3", "parameter preset (pasta3, pasta4)")
+	modulus := fs.Uint64("modulus", 65537, "plaintext prime modulus")
+	nonce := fs.Uint64("nonce", pasta.DefaultNonce, "nonce")
+	block := fs.Uint64("block", 0, "first block counter")
+	blocks := fs.Uint64("blocks", 16, "number of blocks")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	params, ok := presets[*preset]
+	if !ok {
+		return fmt.Errorf("unknown params preset %q", *preset)
+	}
+	if err := checkModulus(*modulus); err != nil {
+		return err
+	}
+
+	singular, checked, err := pasta.FindSingularMatrices(*modulus, params, *nonce, *block, *blocks)
+	if err != nil {
+		return err
+	}
+	for _, m := range singular {
+		fmt.Fprintf(stdout, "block %d, round %d, half %d: rank %d of %d\n",
+			m.BlockCounter, m.Round, m.Half, m.Rank, params.SecretKeySize/2)
+	}
+	fmt.Fprintf(stdout, "%s, p = %d: %d of %d matrices singular\n", *preset, *modulus, len(singular), checked)
+
+	if len(singular) > 0 {
+		return fmt.Errorf("%d singular matrices", len(singular))
+	}
+	return nil
+}
+
+func selfTest(args []string, _ io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := pasta.SelfTest(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, "self-test passed")
+	return nil
+}
+
+func printHeader(w io.Writer, modulus uint64, params pasta.Params) {
+	name := "custom"
+	for preset, p := range presets {
+		if p == params {
+			name = preset
+		}
+	}
+
+	fmt.Fprintf(w, "params:    %s (key %d, block %d, rounds %d)\n",
+		name, params.SecretKeySize, params.PlainSize, params.Rounds)
+	fmt.Fprintf(w, "modulus:   %d (%d bits)\n", modulus, pasta.ElementBits(modulus))
+}
+
+// checkModulus rejects a -modulus that is not prime.
+func checkModulus(modulus uint64) error {
+	if modulus < 3 || !new(big.Int).SetUint64(modulus).ProbablyPrime(20) {
+		return fmt.Errorf("modulus %d is not an odd prime", modulus)
+	}
+	return nil
+}
+
+// randomNonce draws a nonce from crypto/rand, so that encryptions without
+// -nonce never reuse one in practice.
+func randomNonce() (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func readKey(path string) (*pasta.Key, error) {
+	if path == "" {
+		return nil, errors.New("missing -key")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key pasta.Key
+	if err := key.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &key, nil
+}
+
+func openInput(path string, stdin io.Reader) (io.ReadCloser, error) {
+	if path == "" || path == "-" {
+		return io.NopCloser(stdin), nil
+	}
+	return os.Open(path)
+}
+
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	r, err := openInput(path, stdin)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// writeOutput writes data to path, or to stdout when path is empty. Key
+// files are created readable by the owner only.
+func writeOutput(path string, stdout io.Writer, data []byte) error {
+	if path == "" || path == "-" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "key")
+	ct := filepath.Join(dir, "ct")
+
+	steps := []struct {
+		args  []string
+		stdin string
+	}{
+		{[]string{"keygen", "-params", "pasta4", "-modulus", "65537", "-out", key}, ""},
+		{[]string{"encrypt", "-key", key, "-nonce", "7", "-format", "hex", "-out", ct}, "0x1 0x2 0xffff"},
+	}
+	for _, step := range steps {
+		var stderr bytes.Buffer
+		if code := run(step.args, strings.NewReader(step.stdin), &bytes.Buffer{}, &stderr); code != 0 {
+			t.Fatalf("%v: exit %d: %s", step.args, code, stderr.String())
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"decrypt", "-key", key, "-in", ct}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("decrypt: exit %d: %s", code, stderr.String())
+	}
+	if got := stdout.String(); got != "1\n2\n65535\n" {
+		t.Errorf("decrypt: got %q", got)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"inspect", ct}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("inspect: exit %d: %s", code, stderr.String())
+	}
+	for _, want := range []string{"container", "pasta4", "65537", "nonce:     7", "elements:  3"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("inspect output lacks %q:\n%s", want, stdout.String())
+		}
+	}
+
+	if code := run([]string{"encrypt", "-key", key}, strings.NewReader("65537"), &stdout, &stderr); code != 1 {
+		t.Errorf("unreduced plaintext: exit %d", code)
+	}
+
+	// without -nonce every encryption draws a fresh random nonce
+	var first, second bytes.Buffer
+	for _, out := range []*bytes.Buffer{&first, &second} {
+		if code := run([]string{"encrypt", "-key", key}, strings.NewReader("1 2 3"), out, &stderr); code != 0 {
+			t.Fatalf("encrypt: exit %d: %s", code, stderr.String())
+		}
+	}
+	if bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("encrypt without -nonce reused the nonce")
+	}
+	stdout.Reset()
+	if code := run([]string{"decrypt", "-key", key}, &first, &stdout, &stderr); code != 0 {
+		t.Fatalf("decrypt: exit %d: %s", code, stderr.String())
+	}
+	if got := stdout.String(); got != "1\n2\n3\n" {
+		t.Errorf("decrypt: got %q", got)
+	}
+
+	for _, args := range [][]string{
+		{"keygen", "-modulus", "65535"},
+		{"keygen", "-modulus", "2"},
+		{"matrices", "-modulus", "65535"},
+		{"vectors", "-modulus", "65535"},
+		{"randtest", "-modulus", "65535"},
+	} {
+		if code := run(args, nil, &bytes.Buffer{}, &stderr); code != 1 {
+			t.Errorf("%v: exit %d, expected a composite modulus to fail", args, code)
+		}
+	}
+
+	vectors := filepath.Join(dir, "vectors")
+	if code := run([]string{"vectors", "-params", "pasta4", "-n", "2", "-seed", "5", "-out", vectors}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("vectors: exit %d: %s", code, stderr.String())
+	}
+	stdout.Reset()
+	if code := run([]string{"vectors", "-verify", vectors}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("vectors -verify: exit %d: %s", code, stderr.String())
+	}
+	if got := stdout.String(); got != "2 vectors ok\n" {
+		t.Errorf("vectors -verify: got %q", got)
+	}
+}
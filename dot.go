@@ -0,0 +1,83 @@
+package pasta
+
+import "math/bits"
+
+// vectorMaxModulus bounds the moduli handled by schoolbookVector: reduced
+// elements split into a 17-bit low limb and a high limb below 2^16, and
+// every limb product stays below 2^34.
+const vectorMaxModulus = 1 << 33
+
+const limbBits = 17
+const limbMask = 1<<limbBits - 1
+
+// dot17Generic returns sum a[i] * b[i] for a[i], b[i] < 2^17 and fewer than
+// 2^30 terms, so the sum cannot overflow.
+func dot17Generic(a, b []uint32) uint64 {
+	sum := uint64(0)
+	for i := range a {
+		sum += uint64(a[i]) * uint64(b[i])
+	}
+	return sum
+}
+
+// dot17x3Generic returns the limb products of two vectors of 34-bit
+// elements: lo.lo, lo.hi + hi.lo and hi.hi.
+func dot17x3Generic(aLo, aHi, bLo, bHi []uint32) (s0, s1, s2 uint64) {
+	for i := range aLo {
+		s0 += uint64(aLo[i]) * uint64(bLo[i])
+		s1 += uint64(aLo[i])*uint64(bHi[i]) + uint64(aHi[i])*uint64(bLo[i])
+		s2 += uint64(aHi[i]) * uint64(bHi[i])
+	}
+	return s0, s1, s2
+}
+
+// schoolbookVector is schoolbook for moduli below 2^33, with the products of
+// 17-bit limbs accumulated by dot17 and reduced once per coefficient.
+func (p *UtilOf[T]) schoolbookVector(out, a, b []T) {
+	aLo, aHi := p.splitLimbs(a, false)
+	bLo, bHi := p.splitLimbs(b, true)
+	wide := p.modulus > limbMask+1
+
+	for k := range out[:len(a)+len(b)-1] {
+		lo := k - len(b) + 1
+		if lo < 0 {
+			lo = 0
+		}
+		hi := k + 1
+		if hi > len(a) {
+			hi = len(a)
+		}
+		// b[k-i] is bRev[off+i]
+		off := len(b) - 1 - k
+
+		var r uint64
+		if wide {
+			// s0 + s1 2^17 + s2 2^34
+			s0, s1, s2 := dot17x3(aLo[lo:hi], aHi[lo:hi], bLo[off+lo:off+hi], bHi[off+lo:off+hi])
+			sumLo, carry := bits.Add64(s0, s1<<limbBits, 0)
+			sumHi := s1>>(64-limbBits) + carry
+			sumLo, carry = bits.Add64(sumLo, s2<<(2*limbBits), 0)
+			sumHi += s2>>(64-2*limbBits) + carry
+			r = bits.Rem64(sumHi, sumLo, p.modulus)
+		} else {
+			r = dot17(aLo[lo:hi], bLo[off+lo:off+hi]) % p.modulus
+		}
+
+		out[k] = p.field_.Add(out[k], T(r))
+	}
+}
+
+// splitLimbs returns the low and high 17-bit limbs of v, optionally reversed.
+func (p *UtilOf[T]) splitLimbs(v []T, reverse bool) ([]uint32, []uint32) {
+	lo := p.allocLimbs(len(v))
+	hi := p.allocLimbs(len(v))
+	for i, x := range v {
+		j := i
+		if reverse {
+			j = len(v) - 1 - i
+		}
+		lo[j] = uint32(x & limbMask)
+		hi[j] = uint32(x >> limbBits)
+	}
+	return lo, hi
+}
@@ -0,0 +1,57 @@
+//go:build amd64 && !purego
+
+package pasta
+
+// useAVX2 selects the AVX2 dot17 kernel, see detectAVX2.
+var useAVX2 = detectAVX2()
+
+//go:noescape
+func dot17AVX2(a, b []uint32) uint64
+
+//go:noescape
+func dot17x3AVX2(aLo, aHi, bLo, bHi []uint32, sums *[3]uint64)
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func xgetbv() (eax, edx uint32)
+
+func detectAVX2() bool {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 7 {
+		return false
+	}
+
+	// the OS must save the YMM registers
+	_, _, ecx1, _ := cpuid(1, 0)
+	osxsave := ecx1&(1<<27) != 0
+	avx := ecx1&(1<<28) != 0
+	if !osxsave || !avx {
+		return false
+	}
+	if xcr0, _ := xgetbv(); xcr0&6 != 6 {
+		return false
+	}
+
+	_, ebx7, _, _ := cpuid(7, 0)
+	return ebx7&(1<<5) != 0
+}
+
+func dot17(a, b []uint32) uint64 {
+	if useAVX2 {
+		return dot17AVX2(a, b)
+	}
+	return dot17Generic(a, b)
+}
+
+func dot17x3(aLo, aHi, bLo, bHi []uint32) (s0, s1, s2 uint64) {
+	if !useAVX2 {
+		return dot17x3Generic(aLo, aHi, bLo, bHi)
+	}
+
+	var sums [3]uint64
+	dot17x3AVX2(aLo, aHi, bLo, bHi, &sums)
+
+	n := len(aLo) &^ 3
+	t0, t1, t2 := dot17x3Generic(aLo[n:], aHi[n:], bLo[n:], bHi[n:])
+	return sums[0] + t0, sums[1] + t1, sums[2] + t2
+}
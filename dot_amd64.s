@@ -0,0 +1,138 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// func dot17AVX2(a, b []uint32) uint64
+TEXT ·dot17AVX2(SB), NOSPLIT, $0-56
+	MOVQ a_base+0(FP), SI
+	MOVQ a_len+8(FP), CX
+	MOVQ b_base+24(FP), DI
+	VPXOR Y0, Y0, Y0
+	VPXOR Y4, Y4, Y4
+
+loop8:
+	CMPQ      CX, $8
+	JB        loop4
+	VPMOVZXDQ (SI), Y1
+	VPMOVZXDQ (DI), Y2
+	VPMULUDQ  Y1, Y2, Y3
+	VPADDQ    Y3, Y0, Y0
+	VPMOVZXDQ 16(SI), Y1
+	VPMOVZXDQ 16(DI), Y2
+	VPMULUDQ  Y1, Y2, Y3
+	VPADDQ    Y3, Y4, Y4
+	ADDQ      $32, SI
+	ADDQ      $32, DI
+	SUBQ      $8, CX
+	JMP       loop8
+
+loop4:
+	CMPQ      CX, $4
+	JB        reduce
+	VPMOVZXDQ (SI), Y1
+	VPMOVZXDQ (DI), Y2
+	VPMULUDQ  Y1, Y2, Y3
+	VPADDQ    Y3, Y0, Y0
+	ADDQ      $16, SI
+	ADDQ      $16, DI
+	SUBQ      $4, CX
+
+reduce:
+	// horizontal sum of the 8 lanes
+	VPADDQ       Y4, Y0, Y0
+	VEXTRACTI128 $1, Y0, X1
+	VPADDQ       X1, X0, X0
+	VPSHUFD      $0x4e, X0, X1
+	VPADDQ       X1, X0, X0
+	VMOVQ        X0, AX
+	VZEROUPPER
+
+tail:
+	TESTQ CX, CX
+	JZ    done
+	MOVL  (SI), R8
+	MOVL  (DI), R9
+	IMULQ R9, R8
+	ADDQ  R8, AX
+	ADDQ  $4, SI
+	ADDQ  $4, DI
+	DECQ  CX
+	JMP   tail
+
+done:
+	MOVQ AX, ret+48(FP)
+	RET
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax, edx uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	MOVL DX, edx+4(FP)
+	RET
+
+// func dot17x3AVX2(aLo, aHi, bLo, bHi []uint32, sums *[3]uint64)
+// processes the first len(aLo) &^ 3 elements.
+TEXT ·dot17x3AVX2(SB), NOSPLIT, $0-104
+	MOVQ aLo_base+0(FP), SI
+	MOVQ aLo_len+8(FP), CX
+	MOVQ aHi_base+24(FP), R8
+	MOVQ bLo_base+48(FP), DI
+	MOVQ bHi_base+72(FP), R9
+	MOVQ sums+96(FP), DX
+	SHRQ $2, CX
+	VPXOR Y0, Y0, Y0
+	VPXOR Y4, Y4, Y4
+	VPXOR Y7, Y7, Y7
+
+loop:
+	TESTQ     CX, CX
+	JZ        reduce
+	VPMOVZXDQ (SI), Y1
+	VPMOVZXDQ (R8), Y2
+	VPMOVZXDQ (DI), Y5
+	VPMOVZXDQ (R9), Y6
+	VPMULUDQ  Y1, Y5, Y3
+	VPADDQ    Y3, Y0, Y0
+	VPMULUDQ  Y1, Y6, Y3
+	VPADDQ    Y3, Y4, Y4
+	VPMULUDQ  Y2, Y5, Y3
+	VPADDQ    Y3, Y4, Y4
+	VPMULUDQ  Y2, Y6, Y3
+	VPADDQ    Y3, Y7, Y7
+	ADDQ      $16, SI
+	ADDQ      $16, R8
+	ADDQ      $16, DI
+	ADDQ      $16, R9
+	DECQ      CX
+	JMP       loop
+
+reduce:
+	VEXTRACTI128 $1, Y0, X1
+	VPADDQ       X1, X0, X0
+	VPSHUFD      $0x4e, X0, X1
+	VPADDQ       X1, X0, X0
+	VMOVQ        X0, 0(DX)
+	VEXTRACTI128 $1, Y4, X1
+	VPADDQ       X1, X4, X4
+	VPSHUFD      $0x4e, X4, X1
+	VPADDQ       X1, X4, X4
+	VMOVQ        X4, 8(DX)
+	VEXTRACTI128 $1, Y7, X1
+	VPADDQ       X1, X7, X7
+	VPSHUFD      $0x4e, X7, X1
+	VPADDQ       X1, X7, X7
+	VMOVQ        X7, 16(DX)
+	VZEROUPPER
+	RET
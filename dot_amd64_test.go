@@ -0,0 +1,30 @@
+//go:build amd64 && !purego
+
+package pasta
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestKeystreamWithAndWithoutAVX2(t *testing.T) {
+	if !useAVX2 {
+		t.Skip("AVX2 not available")
+	}
+	defer func() { useAVX2 = true }()
+
+	rng := rand.New(rand.NewSource(4))
+	for _, modulus := range []uint64{65537, 8088322049} {
+		secretKey := randomVector(rng, SecretKeySize, modulus)
+
+		useAVX2 = true
+		vector := NewUtil(secretKey, modulus, 3)
+		expected := vector.Keystream(DefaultNonce, 5)
+
+		useAVX2 = false
+		scalar := NewUtil(secretKey, modulus, 3)
+		if ks := scalar.Keystream(DefaultNonce, 5); !equalSlices(ks, expected) {
+			t.Errorf("modulus %d: scalar(%d), avx2(%d)", modulus, ks, expected)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !amd64 || purego
+
+package pasta
+
+const useAVX2 = false
+
+func dot17(a, b []uint32) uint64 {
+	return dot17Generic(a, b)
+}
+
+func dot17x3(aLo, aHi, bLo, bHi []uint32) (s0, s1, s2 uint64) {
+	return dot17x3Generic(aLo, aHi, bLo, bHi)
+}
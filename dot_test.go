@@ -0,0 +1,79 @@
+package pasta
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDot17(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 70; n++ {
+		a := make([]uint32, n)
+		b := make([]uint32, n)
+		for i := range a {
+			a[i] = uint32(rng.Intn(limbMask + 1))
+			b[i] = uint32(rng.Intn(limbMask + 1))
+		}
+
+		if got, expected := dot17(a, b), dot17Generic(a, b); got != expected {
+			t.Errorf("n %d: dot17 %d, expected %d", n, got, expected)
+		}
+
+		s0, s1, s2 := dot17x3(a, b, b, a)
+		e0, e1, e2 := dot17x3Generic(a, b, b, a)
+		if s0 != e0 || s1 != e1 || s2 != e2 {
+			t.Errorf("n %d: dot17x3 (%d, %d, %d), expected (%d, %d, %d)", n, s0, s1, s2, e0, e1, e2)
+		}
+	}
+}
+
+func TestSchoolbookVectorMatchesScalar(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+
+	for _, modulus := range []uint64{7, 65537, 131071, 8088322049, vectorMaxModulus - 9} {
+		util := NewUtil(nil, modulus, 3)
+		for _, sizes := range [][2]int{{1, 1}, {5, 3}, {31, 31}, {31, 7}, {64, 64}} {
+			a := randomVector(rng, sizes[0], modulus)
+			b := randomVector(rng, sizes[1], modulus)
+
+			scalar := make([]uint64, len(a)+len(b)-1)
+			vector := make([]uint64, len(a)+len(b)-1)
+			util.schoolbook(scalar, a, b)
+			util.schoolbookVector(vector, a, b)
+
+			if !equalSlices(scalar, vector) {
+				t.Errorf("modulus %d, sizes %v: scalar(%d), vector(%d)", modulus, sizes, scalar, vector)
+			}
+		}
+	}
+}
+
+func BenchmarkSchoolbook(b *testing.B) {
+	rng := rand.New(rand.NewSource(3))
+
+	for _, modulus := range []uint64{65537, 8088322049} {
+		util := NewUtil(nil, modulus, 3)
+		x := randomVector(rng, karatsubaThreshold-1, modulus)
+		y := randomVector(rng, karatsubaThreshold-1, modulus)
+		out := make([]uint64, len(x)+len(y)-1)
+
+		b.Run("scalar/"+bitsOf(modulus), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				util.schoolbook(out, x, y)
+			}
+		})
+		b.Run("vector/"+bitsOf(modulus), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				util.schoolbookVector(out, x, y)
+			}
+		})
+	}
+}
+
+func bitsOf(modulus uint64) string {
+	if modulus < 1<<17 {
+		return "17bit"
+	}
+	return "33bit"
+}
@@ -0,0 +1,203 @@
+package pasta
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// ElementFormat is the external representation of field elements read and
+// written by ReadElements and WriteElements.
+type ElementFormat int
+
+const (
+	// whitespace- or comma-separated decimal numbers
+	FormatDecimal ElementFormat = iota
+	// whitespace- or comma-separated hexadecimal numbers, 0x optional
+	FormatHex
+	// 8-byte big-endian words
+	FormatBinary
+)
+
+func ParseElementFormat(s string) (ElementFormat, error) {
+	switch s {
+	case "dec", "decimal":
+		return FormatDecimal, nil
+	case "hex":
+		return FormatHex, nil
+	case "bin", "binary":
+		return FormatBinary, nil
+	}
+	return 0, fmt.Errorf("unknown element format %q", s)
+}
+
+func (f ElementFormat) String() string {
+	switch f {
+	case FormatDecimal:
+		return "dec"
+	case FormatHex:
+		return "hex"
+	case FormatBinary:
+		return "bin"
+	}
+	return fmt.Sprintf("ElementFormat(%d)", int(f))
+}
+
+func ReadElements(r io.Reader, format ElementFormat) ([]uint64, error) {
+	if format == FormatBinary {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(data)%8 != 0 {
+			return nil, fmt.Errorf("binary elements: %d bytes is not a multiple of 8", len(data))
+		}
+		elements := make([]uint64, len(data)/8)
+		for i := range elements {
+			elements[i] = binary.BigEndian.Uint64(data[8*i:])
+		}
+		return elements, nil
+	}
+
+	base := 10
+	if format == FormatHex {
+		base = 16
+	} else if format != FormatDecimal {
+		return nil, fmt.Errorf("unknown element format %v", format)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanTokens)
+
+	var elements []uint64
+	for scanner.Scan() {
+		token := scanner.Text()
+		if base == 16 {
+			token = strings.TrimPrefix(strings.TrimPrefix(token, "0x"), "0X")
+		}
+		e, err := strconv.ParseUint(token, base, 64)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", len(elements), err)
+		}
+		elements = append(elements, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return elements, nil
+}
+
+func WriteElements(w io.Writer, elements []uint64, format ElementFormat) error {
+	bw := bufio.NewWriter(w)
+
+	for _, e := range elements {
+		var err error
+		switch format {
+		case FormatDecimal:
+			_, err = fmt.Fprintf(bw, "%d\n", e)
+		case FormatHex:
+			_, err = fmt.Fprintf(bw, "0x%x\n", e)
+		case FormatBinary:
+			var word [8]byte
+			binary.BigEndian.PutUint64(word[:], e)
+			_, err = bw.Write(word[:])
+		default:
+			err = fmt.Errorf("unknown element format %v", format)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// scanTokens splits on whitespace and commas.
+func scanTokens(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	isSep := func(b byte) bool {
+		return b == ',' || b == ' ' || b == '\t' || b == '\n' || b == '\r'
+	}
+
+	start := 0
+	for start < len(data) && isSep(data[start]) {
+		start++
+	}
+	for i := start; i < len(data); i++ {
+		if isSep(data[i]) {
+			return i + 1, data[start:i], nil
+		}
+	}
+	if atEOF && len(data) > start {
+		return len(data), data[start:], nil
+	}
+
+	return start, nil, nil
+}
+
+// ElementBits is the width of a packed element mod modulus.
+func ElementBits(modulus uint64) int {
+	return bits.Len64(modulus - 1)
+}
+
+// PackedSize is the number of bytes of count packed elements.
+func PackedSize(count int, modulus uint64) int {
+	return (count*ElementBits(modulus) + 7) / 8
+}
+
+// PackElements concatenates the ElementBits(modulus) low bits of every
+// element, most significant bit first, padding the last byte with zeros.
+func PackElements(elements []uint64, modulus uint64) []byte {
+	width := ElementBits(modulus)
+	out := make([]byte, PackedSize(len(elements), modulus))
+
+	pos := 0
+	for _, e := range elements {
+		for b := width - 1; b >= 0; b-- {
+			if e>>uint(b)&1 == 1 {
+				out[pos/8] |= 0x80 >> uint(pos%8)
+			}
+			pos++
+		}
+	}
+
+	return out
+}
+
+var ErrPackedLength = errors.New("packed elements: unexpected length")
+
+// UnpackElements reverses PackElements, rejecting elements that are not
+// reduced mod modulus and non-zero padding.
+func UnpackElements(data []byte, count int, modulus uint64) ([]uint64, error) {
+	if count < 0 || len(data) != PackedSize(count, modulus) {
+		return nil, ErrPackedLength
+	}
+
+	width := ElementBits(modulus)
+	elements := make([]uint64, count)
+
+	pos := 0
+	for i := range elements {
+		var e uint64
+		for b := 0; b < width; b++ {
+			e = e<<1 | uint64(data[pos/8]>>(7-uint(pos%8))&1)
+			pos++
+		}
+		if e >= modulus {
+			return nil, fmt.Errorf("packed element %d: %d is not reduced mod %d", i, e, modulus)
+		}
+		elements[i] = e
+	}
+	for ; pos < 8*len(data); pos++ {
+		if data[pos/8]>>(7-uint(pos%8))&1 != 0 {
+			return nil, fmt.Errorf("packed elements: non-zero padding")
+		}
+	}
+
+	return elements, nil
+}
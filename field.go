@@ -0,0 +1,151 @@
+package pasta
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+type reductionKind int
+
+const (
+	// bits.Rem64 on the 128-bit product
+	reductionGeneric reductionKind = iota
+	// p = 2^k + 1, 2^k = -1 mod p
+	reductionFermat
+	// p = 2^k - c with c < 2^(k/2), 2^k = c mod p
+	reductionPseudoMersenne
+)
+
+// field implements arithmetic mod modulus, reducing products with a
+// dedicated routine when the modulus has a special form.
+type field struct {
+	modulus uint64
+
+	kind reductionKind
+	k    uint
+	mask uint64
+	c    uint64
+}
+
+func newField(modulus uint64) field {
+	f := field{modulus: modulus, kind: reductionGeneric}
+
+	k := uint(bits.Len64(modulus))
+	switch {
+	case modulus > 2 && k <= 32 && modulus == 1<<(k-1)+1:
+		// products of reduced elements fit in 64 bits
+		f.kind = reductionFermat
+		f.k = k - 1
+	case modulus > 2 && k <= 63 && (1<<k)-modulus < 1<<(k/2):
+		f.kind = reductionPseudoMersenne
+		f.k = k
+		f.c = (1 << k) - modulus
+	}
+	f.mask = 1<<f.k - 1
+
+	return f
+}
+
+// a + b, for a, b < modulus
+func (f *field) add(a, b uint64) uint64 {
+	return addMod(a, b, f.modulus)
+}
+
+// a - b, for a, b < modulus
+func (f *field) sub(a, b uint64) uint64 {
+	return subMod(a, b, f.modulus)
+}
+
+// a * b, for a, b < modulus
+func (f *field) mul(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return f.reduce(hi, lo)
+}
+
+// reduce returns hi * 2^64 + lo mod modulus, for inputs below modulus^2.
+func (f *field) reduce(hi, lo uint64) uint64 {
+	switch f.kind {
+	case reductionFermat:
+		return f.reduceFermat(lo)
+	case reductionPseudoMersenne:
+		return f.reducePseudoMersenne(hi, lo)
+	default:
+		return bits.Rem64(hi, lo, f.modulus)
+	}
+}
+
+// x = h 2^k + l = l - h mod 2^k + 1, for x < 2^(2k+2)
+func (f *field) reduceFermat(x uint64) uint64 {
+	l, h := x&f.mask, x>>f.k
+
+	// h < 2^(k+2) needs one more fold, its top part is at most 3
+	h = (h & f.mask) + f.modulus - (h >> f.k)
+	for h >= f.modulus {
+		h -= f.modulus
+	}
+
+	return subMod(l, h, f.modulus)
+}
+
+// x = h 2^k + l = l + c h mod 2^k - c
+func (f *field) reducePseudoMersenne(hi, lo uint64) uint64 {
+	for hi != 0 || lo > f.mask {
+		// h = x >> k, l = x & (2^k - 1); h < 2^64 since x < 2^2k
+		h := hi<<(64-f.k) | lo>>f.k
+		l := lo & f.mask
+
+		var carry uint64
+		hi, lo = bits.Mul64(h, f.c)
+		lo, carry = bits.Add64(lo, l, 0)
+		hi += carry
+	}
+
+	for lo >= f.modulus {
+		lo -= f.modulus
+	}
+	return lo
+}
+
+// Word is the machine word storing field elements. uint32 halves the memory
+// of instances whose modulus is below 2^32.
+type Word interface {
+	uint32 | uint64
+}
+
+// Field is arithmetic mod a modulus on elements stored as T. Intermediate
+// results are computed in 64 or 128 bits, so every T is safe for any
+// modulus that fits in it.
+type Field[T Word] struct {
+	f field
+}
+
+func NewField[T Word](modulus uint64) (Field[T], error) {
+	if modulus < 2 || uint64(T(modulus)) != modulus {
+		return Field[T]{}, fmt.Errorf("modulus %d does not fit in %d bits", modulus, bits.Len64(uint64(^T(0))))
+	}
+	return Field[T]{newField(modulus)}, nil
+}
+
+func (f Field[T]) Modulus() uint64 {
+	return f.f.modulus
+}
+
+// a + b, for a, b < modulus
+func (f Field[T]) Add(a, b T) T {
+	return T(f.f.add(uint64(a), uint64(b)))
+}
+
+// a - b, for a, b < modulus
+func (f Field[T]) Sub(a, b T) T {
+	return T(f.f.sub(uint64(a), uint64(b)))
+}
+
+// a * b, for a, b < modulus
+func (f Field[T]) Mul(a, b T) T {
+	return T(f.f.mul(uint64(a), uint64(b)))
+}
+
+// Reduce returns x mod modulus for any x.
+func (f Field[T]) Reduce(x uint64) T {
+	return T(x % f.f.modulus)
+}
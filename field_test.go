@@ -0,0 +1,126 @@
+package pasta
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestNewFieldDetectsSpecialForms(t *testing.T) {
+	testCases := []struct {
+		modulus uint64
+		kind    reductionKind
+	}{
+		{257, reductionFermat},
+		{65537, reductionFermat},
+		{131071, reductionPseudoMersenne},              // 2^17 - 1
+		{2147483647, reductionPseudoMersenne},          // 2^31 - 1
+		{4294967291, reductionPseudoMersenne},          // 2^32 - 5
+		{2305843009213693951, reductionPseudoMersenne}, // 2^61 - 1
+		{4611686018427387847, reductionPseudoMersenne}, // 2^62 - 57
+		{8088322049, reductionGeneric},
+		{1096486890805657601, reductionGeneric},
+	}
+
+	for _, tc := range testCases {
+		if f := newField(tc.modulus); f.kind != tc.kind {
+			t.Errorf("modulus %d: kind %d, expected %d", tc.modulus, f.kind, tc.kind)
+		}
+	}
+}
+
+func TestFieldMulMatchesBigInt(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	moduli := []uint64{7, 257, 65537, 131071, 2147483647, 4294967291, 8088322049,
+		2305843009213693951, 4611686018427387847, 1096486890805657601}
+
+	for _, modulus := range moduli {
+		f := newField(modulus)
+		m := new(big.Int).SetUint64(modulus)
+
+		check := func(a, b uint64) {
+			expected := new(big.Int).Mul(new(big.Int).SetUint64(a), new(big.Int).SetUint64(b))
+			expected.Mod(expected, m)
+			if got := f.mul(a, b); got != expected.Uint64() {
+				t.Fatalf("modulus %d: %d * %d = %d, expected %d", modulus, a, b, got, expected)
+			}
+		}
+
+		// edge values
+		for _, a := range []uint64{0, 1, 2, modulus - 2, modulus - 1} {
+			for _, b := range []uint64{0, 1, 2, modulus - 2, modulus - 1} {
+				if a < modulus && b < modulus {
+					check(a, b)
+				}
+			}
+		}
+		for i := 0; i < 20000; i++ {
+			check(rng.Uint64()%modulus, rng.Uint64()%modulus)
+		}
+	}
+}
+
+func BenchmarkFieldMul(b *testing.B) {
+	for _, modulus := range []uint64{65537, 2305843009213693951, 1096486890805657601} {
+		f := newField(modulus)
+		x, y := modulus-3, modulus-5
+		b.Run(fmt.Sprint(modulus), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				x = f.mul(x, y)
+			}
+		})
+	}
+}
+
+func TestNewFieldRejectsWideModuli(t *testing.T) {
+	if _, err := NewField[uint32](8088322049); err == nil {
+		t.Errorf("expected error for a 33-bit modulus in uint32")
+	}
+	if _, err := NewField[uint32](1); err == nil {
+		t.Errorf("expected error for modulus 1")
+	}
+	if _, err := NewField[uint32](4294967291); err != nil {
+		t.Errorf("unexpected error for a 32-bit modulus: %v", err)
+	}
+}
+
+func TestPasta32MatchesPasta64(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+
+	for _, modulus := range []uint64{7, 65537, 4294967291} {
+		for _, params := range []Params{Pasta3, Pasta4} {
+			secretKey := randomVector(rng, int(params.SecretKeySize), modulus)
+			plaintext := randomVector(rng, int(params.PlainSize)+5, modulus)
+
+			secretKey32 := make([]uint32, len(secretKey))
+			for i, k := range secretKey {
+				secretKey32[i] = uint32(k)
+			}
+			plaintext32 := make([]uint32, len(plaintext))
+			for i, m := range plaintext {
+				plaintext32[i] = uint32(m)
+			}
+
+			pasta64 := NewPasta(secretKey, modulus, params)
+			pasta32 := NewPastaOf(secretKey32, modulus, params)
+
+			ciphertext := pasta64.Encrypt(plaintext)
+			ciphertext32 := pasta32.Encrypt(plaintext32)
+			for i := range ciphertext {
+				if uint64(ciphertext32[i]) != ciphertext[i] {
+					t.Fatalf("modulus %d, %v: ciphertext[%d] = %d, expected %d",
+						modulus, params, i, ciphertext32[i], ciphertext[i])
+				}
+			}
+
+			decrypted32 := pasta32.Decrypt(ciphertext32)
+			for i := range plaintext {
+				if uint64(decrypted32[i]) != plaintext[i] {
+					t.Fatalf("modulus %d, %v: decrypted[%d] = %d, expected %d",
+						modulus, params, i, decrypted32[i], plaintext[i])
+				}
+			}
+		}
+	}
+}
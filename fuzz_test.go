@@ -0,0 +1,223 @@
+package pasta
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// the seed corpus of every target is in testdata/fuzz
+
+func FuzzEncryptDecrypt(f *testing.F) {
+	f.Add(int64(1), uint64(65537), false, uint8(127), uint8(127), uint8(2), uint16(0), uint64(DefaultNonce))
+	f.Add(int64(2), uint64(8088322049), false, uint8(31), uint8(31), uint8(3), uint16(33), uint64(0))
+	f.Add(int64(3), uint64(17), true, uint8(4), uint8(2), uint8(0), uint16(17), uint64(9))
+	f.Add(int64(4), uint64(1)<<63, false, uint8(31), uint8(31), uint8(3), uint16(40), uint64(5))
+	f.Add(int64(5), ^uint64(0), false, uint8(7), uint8(3), uint8(1), uint16(20), uint64(6))
+
+	// the modulus is the first prime from the input on, below 2^32 when
+	// narrow, which also runs PastaOf[uint32]. The params are any valid
+	// ones: a key of 2t elements for t in 1..128, a block of 1..t
+	// elements and 1..4 rounds, which covers Pasta3 and Pasta4.
+	f.Fuzz(func(t *testing.T, seed int64, modulus uint64, narrow bool, half, plain, rounds uint8, length uint16, nonce uint64) {
+		if narrow {
+			modulus = nextPrime(modulus%(1<<32), 4294967291)
+		} else {
+			modulus = nextPrime(modulus, 18446744073709551557)
+		}
+		stateSize := uint64(half)%128 + 1
+		blockSize := uint64(plain)%stateSize + 1
+		params := Params{2 * stateSize, blockSize, blockSize, uint(rounds)%4 + 1}
+		if length > 1024 {
+			t.Skip()
+		}
+		if err := validatePastaParams(params); err != nil {
+			t.Fatalf("params %v: %v", params, err)
+		}
+
+		rng := rand.New(rand.NewSource(seed))
+		secretKey := randomVector(rng, int(params.SecretKeySize), modulus)
+		pasta := NewPasta(secretKey, modulus, params)
+		plaintext := randomVector(rng, int(length), modulus)
+
+		ciphertext := pasta.EncryptWithNonce(plaintext, nonce)
+		if len(ciphertext) != len(plaintext) {
+			t.Fatalf("%d ciphertext elements for %d plaintext elements", len(ciphertext), len(plaintext))
+		}
+		for i, c := range ciphertext {
+			if c >= modulus {
+				t.Fatalf("ciphertext[%d] = %d is not reduced mod %d", i, c, modulus)
+			}
+		}
+		if !equalSlices(pasta.DecryptWithNonce(ciphertext, nonce), plaintext) {
+			t.Fatal("Decrypt does not invert Encrypt")
+		}
+
+		inPlace := append([]uint64(nil), plaintext...)
+		pasta.EncryptToWithNonce(inPlace, inPlace, nonce)
+		if !equalSlices(inPlace, ciphertext) {
+			t.Fatal("in-place EncryptTo differs from Encrypt")
+		}
+
+		if !narrow {
+			return
+		}
+		pasta32 := NewPastaOf(narrowVector(secretKey), modulus, params)
+		ciphertext32 := pasta32.EncryptWithNonce(narrowVector(plaintext), nonce)
+		if !equalSlices(ciphertext32, narrowVector(ciphertext)) {
+			t.Fatal("PastaOf[uint32] differs from Pasta")
+		}
+		if !equalSlices(pasta32.DecryptWithNonce(ciphertext32, nonce), narrowVector(plaintext)) {
+			t.Fatal("PastaOf[uint32] Decrypt does not invert Encrypt")
+		}
+	})
+}
+
+// nextPrime is the first odd prime from n on, capped at the prime last.
+func nextPrime(n, last uint64) uint64 {
+	if n < 3 {
+		n = 3
+	}
+	for ; n >= 3 && n < last; n++ {
+		if new(big.Int).SetUint64(n).ProbablyPrime(0) {
+			return n
+		}
+	}
+	return last
+}
+
+func narrowVector(v []uint64) []uint32 {
+	out := make([]uint32, len(v))
+	for i, x := range v {
+		out[i] = uint32(x)
+	}
+	return out
+}
+
+func FuzzUnpackElements(f *testing.F) {
+	f.Add([]byte{0x12, 0x34, 0x56}, 2, uint64(65537))
+	f.Add([]byte{0xe0}, 1, uint64(7))
+
+	f.Fuzz(func(t *testing.T, data []byte, count int, modulus uint64) {
+		if modulus < 2 || count > 8*len(data) {
+			t.Skip()
+		}
+
+		elements, err := UnpackElements(data, count, modulus)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(PackElements(elements, modulus), data) {
+			t.Fatal("packing is not canonical")
+		}
+	})
+}
+
+func FuzzKeyUnmarshal(f *testing.F) {
+	rng := rand.New(rand.NewSource(8))
+	key, _ := GenerateKey(rng, 17, Pasta4)
+	data, _ := key.MarshalBinary()
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var key Key
+		if err := key.UnmarshalBinary(data); err != nil {
+			return
+		}
+		encoded, err := key.MarshalBinary()
+		if err != nil {
+			t.Fatalf("decoded key does not encode: %v", err)
+		}
+		if !bytes.Equal(encoded, data) {
+			t.Fatal("key encoding is not canonical")
+		}
+	})
+}
+
+func FuzzContainerUnmarshal(f *testing.F) {
+	rng := rand.New(rand.NewSource(9))
+	key, _ := GenerateKey(rng, 65537, Pasta4)
+	data, _ := key.Seal([]uint64{1, 2, 3}, 5).MarshalBinary()
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var container Container
+		if err := container.UnmarshalBinary(data); err != nil {
+			return
+		}
+		encoded, err := container.MarshalBinary()
+		if err != nil {
+			t.Fatalf("decoded container does not encode: %v", err)
+		}
+		if !bytes.Equal(encoded, data) {
+			t.Fatal("container encoding is not canonical")
+		}
+	})
+}
+
+func FuzzReadElements(f *testing.F) {
+	f.Add("1 2, 3\n", uint8(FormatDecimal))
+	f.Add("0x10 ff", uint8(FormatHex))
+	f.Add("\x00\x00\x00\x00\x00\x01\x00\x01", uint8(FormatBinary))
+
+	f.Fuzz(func(t *testing.T, input string, format uint8) {
+		elementFormat := ElementFormat(format % 3)
+
+		elements, err := ReadElements(strings.NewReader(input), elementFormat)
+		if err != nil {
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := WriteElements(&buf, elements, elementFormat); err != nil {
+			t.Fatal(err)
+		}
+		again, err := ReadElements(&buf, elementFormat)
+		if err != nil || !equalSlices(again, elements) {
+			t.Fatalf("elements do not survive a write and read: %v", err)
+		}
+	})
+}
+
+func FuzzReadKATs(f *testing.F) {
+	f.Add("count = 0\nmodulus = 17\nparams = 4 2 2 1\nkey = 1 2 3 4\nround0.state1 = 1 2\n")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		kats, err := ReadKATs(strings.NewReader(input))
+		if err != nil {
+			return
+		}
+
+		// verification may fail but must not panic
+		for _, kat := range kats {
+			if kat.Params.SecretKeySize <= 256 && kat.Params.Rounds <= 8 && uint64(len(kat.Plaintext)) <= 4*kat.Params.PlainSize {
+				kat.Verify()
+			}
+		}
+	})
+}
+
+func FuzzReadTrace(f *testing.F) {
+	f.Add("0 matmul 1 state1 1 2 state2 3 4 rand 5 6\n0 mix 0 state1 1 state2 2\n")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		tr, err := ReadTrace(strings.NewReader(input))
+		if err != nil {
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := WriteTrace(&buf, tr); err != nil {
+			t.Fatal(err)
+		}
+		again, err := ReadTrace(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := DiffTraces(tr, again); d != nil {
+			t.Fatalf("trace does not survive a write and read: %v", d)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package pasta
+
+const HeraSecretKeySize = 16
+const HeraPlaintextSize = 16
+const HeraCiphertextSize = 16
+
+var (
+	Hera4 = Params{HeraSecretKeySize, HeraPlaintextSize, HeraCiphertextSize, 4}
+	Hera5 = Params{HeraSecretKeySize, HeraPlaintextSize, HeraCiphertextSize, 5}
+)
+
+type Hera struct {
+	SecretKey    SecretKey
+	Modulus      uint64
+	CipherParams Params
+}
+
+func NewHera(secretKey []uint64, modulus uint64, cipherParams Params) Hera {
+	hera := Hera{
+		secretKey,
+		modulus,
+		cipherParams,
+	}
+
+	return hera
+}
+
+func (h *Hera) Encrypt(plaintext []uint64) []uint64 {
+	return h.EncryptWithNonce(plaintext, DefaultNonce)
+}
+
+func (h *Hera) Decrypt(ciphertext []uint64) []uint64 {
+	return h.DecryptWithNonce(ciphertext, DefaultNonce)
+}
+
+func (h *Hera) EncryptWithNonce(plaintext []uint64, nonce uint64) []uint64 {
+	heraUtil := NewHeraUtil(h.SecretKey, h.Modulus, int(h.CipherParams.Rounds))
+	keystream := func(nonce, blockCounter uint64) []uint64 {
+		ks := heraUtil.Keystream(nonce, blockCounter)
+		return ks[:]
+	}
+
+	return encryptBlocks(plaintext, nonce, h.CipherParams.PlainSize, h.Modulus, keystream)
+}
+
+func (h *Hera) DecryptWithNonce(ciphertext []uint64, nonce uint64) []uint64 {
+	heraUtil := NewHeraUtil(h.SecretKey, h.Modulus, int(h.CipherParams.Rounds))
+	keystream := func(nonce, blockCounter uint64) []uint64 {
+		ks := heraUtil.Keystream(nonce, blockCounter)
+		return ks[:]
+	}
+
+	return decryptBlocks(ciphertext, nonce, h.CipherParams.CipherSize, h.Modulus, keystream)
+}
+
+func (h *Hera) Keystream(nonce uint64, blockCounter uint64) []uint64 {
+	heraUtil := NewHeraUtil(h.SecretKey, h.Modulus, int(h.CipherParams.Rounds))
+	ks := heraUtil.Keystream(nonce, blockCounter)
+	return ks[:]
+}
+
+func (h *Hera) Params() Params {
+	return h.CipherParams
+}
+
+func (h *Hera) PlainModulus() uint64 {
+	return h.Modulus
+}
@@ -0,0 +1,90 @@
+package pasta
+
+import (
+	"math/rand"
+	"testing"
+)
+
+var HeraTestParams = Params{HeraSecretKeySize, HeraPlaintextSize, HeraCiphertextSize, 5}
+
+const HeraTestModulus = 65929217 // 0x3ee0001
+
+func TestHeraEncryptionDecryption(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	secretKey := randomVector(rng, HeraSecretKeySize, HeraTestModulus)
+
+	for _, size := range []int{1, 15, 16, 17, 100} {
+		plaintext := randomVector(rng, size, HeraTestModulus)
+
+		hera := NewHera(secretKey, HeraTestModulus, HeraTestParams)
+		ciphertext := hera.Encrypt(plaintext)
+		decrypted := hera.Decrypt(ciphertext)
+
+		if !equalSlices(decrypted, plaintext) {
+			t.Errorf("size %d: different plaintexts. decrypted(%d), plaintext(%d)",
+				size, decrypted, plaintext)
+		}
+		if equalSlices(ciphertext, plaintext) {
+			t.Errorf("size %d: ciphertext equals plaintext", size)
+		}
+	}
+}
+
+func TestHeraKeystream(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	secretKey := randomVector(rng, HeraSecretKeySize, HeraTestModulus)
+
+	hera := NewHeraUtil(secretKey, HeraTestModulus, 5)
+	ks1 := hera.Keystream(123456789, 0)
+	ks2 := hera.Keystream(123456789, 0)
+	ks3 := hera.Keystream(123456789, 1)
+
+	if ks1 != ks2 {
+		t.Errorf("keystream is not deterministic")
+	}
+	if ks1 == ks3 {
+		t.Errorf("keystream does not depend on the block counter")
+	}
+	for i, e := range ks1 {
+		if e >= HeraTestModulus {
+			t.Errorf("keystream[%d] = %d is not reduced", i, e)
+		}
+	}
+}
+
+func TestHeraMixColumnsRows(t *testing.T) {
+	hera := NewHeraUtil(nil, HeraTestModulus, 5)
+	for i := 0; i < HeraT; i++ {
+		hera.state_[i] = uint64(i + 1)
+	}
+
+	hera.mixColumns()
+	// column 0 is (1, 5, 9, 13)
+	expected := []uint64{2*1 + 3*5 + 9 + 13, 1 + 2*5 + 3*9 + 13, 1 + 5 + 2*9 + 3*13, 3*1 + 5 + 9 + 2*13}
+	for row, e := range expected {
+		if hera.state_[4*row] != e {
+			t.Errorf("mixColumns: state[%d] = %d, expected %d", 4*row, hera.state_[4*row], e)
+		}
+	}
+
+	for i := 0; i < HeraT; i++ {
+		hera.state_[i] = uint64(i + 1)
+	}
+
+	hera.mixRows()
+	// row 0 is (1, 2, 3, 4)
+	expected = []uint64{2*1 + 3*2 + 3 + 4, 1 + 2*2 + 3*3 + 4, 1 + 2 + 2*3 + 3*4, 3*1 + 2 + 3 + 2*4}
+	for col, e := range expected {
+		if hera.state_[col] != e {
+			t.Errorf("mixRows: state[%d] = %d, expected %d", col, hera.state_[col], e)
+		}
+	}
+}
+
+func randomVector(rng *rand.Rand, size int, modulus uint64) []uint64 {
+	v := make([]uint64, size)
+	for i := range v {
+		v[i] = rng.Uint64() % modulus
+	}
+	return v
+}
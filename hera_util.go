@@ -0,0 +1,108 @@
+package pasta
+
+const HeraT = HeraPlaintextSize // state size, viewed as a 4x4 matrix
+
+type HeraBlock [HeraT]uint64
+
+type HeraUtil struct {
+	sampler
+
+	secretKey_ SecretKey
+	state_     HeraBlock
+
+	rounds int
+}
+
+func NewHeraUtil(secretKey []uint64, modulus uint64, rounds int) HeraUtil {
+	var state [HeraT]uint64
+
+	return HeraUtil{
+		newSampler(modulus, nil),
+		secretKey,
+		state,
+		rounds,
+	}
+}
+
+// HERA[r] = Fin o RF[r-1] o ... o RF[1] o ARK applied to the constant
+// state (1, 2, ..., 16), where RF = ARK o Cube o MR o MC and
+// Fin = ARK o MR o MC o Cube o MR o MC.
+func (h *HeraUtil) Keystream(nonce uint64, blockCounter uint64) HeraBlock {
+	h.initShake(nonce, blockCounter)
+
+	// init state
+	for i := 0; i < HeraT; i++ {
+		h.state_[i] = uint64(i+1) % h.modulus
+	}
+
+	h.addRoundKey()
+
+	for r := 1; r < h.rounds; r++ {
+		h.round()
+	}
+
+	// final round
+	h.mixColumns()
+	h.mixRows()
+	h.sboxCube()
+	h.mixColumns()
+	h.mixRows()
+	h.addRoundKey()
+
+	return h.state_
+}
+
+// RF = ARK o Cube o MR o MC
+func (h *HeraUtil) round() {
+	h.mixColumns()
+	h.mixRows()
+	h.sboxCube()
+	h.addRoundKey()
+}
+
+// x + k . rc, with rc sampled from the XOF
+func (h *HeraUtil) addRoundKey() {
+	for i := 0; i < HeraT; i++ {
+		rc := h.generateRandomFieldElement(false)
+		h.state_[i] = addMod(h.state_[i], mulMod(h.secretKey_[i], rc, h.modulus), h.modulus)
+	}
+}
+
+// multiplies every column by circ(2, 3, 1, 1)
+func (h *HeraUtil) mixColumns() {
+	for col := 0; col < 4; col++ {
+		h.mix4(col, col+4, col+8, col+12)
+	}
+}
+
+// multiplies every row by circ(2, 3, 1, 1)
+func (h *HeraUtil) mixRows() {
+	for row := 0; row < 4; row++ {
+		h.mix4(4*row, 4*row+1, 4*row+2, 4*row+3)
+	}
+}
+
+func (h *HeraUtil) mix4(i0, i1, i2, i3 int) {
+	x0, x1, x2, x3 := h.state_[i0], h.state_[i1], h.state_[i2], h.state_[i3]
+
+	h.state_[i0] = h.lincomb(x0, x1, x2, x3)
+	h.state_[i1] = h.lincomb(x1, x2, x3, x0)
+	h.state_[i2] = h.lincomb(x2, x3, x0, x1)
+	h.state_[i3] = h.lincomb(x3, x0, x1, x2)
+}
+
+// 2a + 3b + c + d
+func (h *HeraUtil) lincomb(a, b, c, d uint64) uint64 {
+	sum := mulMod(a, 2, h.modulus)
+	sum = addMod(sum, mulMod(b, 3, h.modulus), h.modulus)
+	sum = addMod(sum, c, h.modulus)
+	return addMod(sum, d, h.modulus)
+}
+
+// [S(x)]i = (x)3
+func (h *HeraUtil) sboxCube() {
+	for i := 0; i < HeraT; i++ {
+		square := mulMod(h.state_[i], h.state_[i], h.modulus)
+		h.state_[i] = mulMod(square, h.state_[i], h.modulus)
+	}
+}
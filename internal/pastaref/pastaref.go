@@ -0,0 +1,172 @@
+// Package pastaref is a slow, literal implementation of the PASTA keystream
+// for differential testing. It follows the specification step by step:
+// matrices are built explicitly and every operation is done with math/big.
+// It shares no code with package pasta.
+package pastaref
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Keystream returns the keystream block blockCounter of PASTA with rounds
+// rounds under key, a vector of 2t elements mod modulus.
+func Keystream(key []uint64, modulus uint64, rounds int, nonce, blockCounter uint64) []uint64 {
+	p := new(big.Int).SetUint64(modulus)
+	t := len(key) / 2
+
+	xof := sha3.NewShake128()
+	seed := make([]byte, 16)
+	binary.BigEndian.PutUint64(seed[:8], nonce)
+	binary.BigEndian.PutUint64(seed[8:], blockCounter)
+	xof.Write(seed)
+
+	s := &sampler{xof, p}
+
+	left := make([]*big.Int, t)
+	right := make([]*big.Int, t)
+	for i := 0; i < t; i++ {
+		left[i] = new(big.Int).Mod(new(big.Int).SetUint64(key[i]), p)
+		right[i] = new(big.Int).Mod(new(big.Int).SetUint64(key[t+i]), p)
+	}
+
+	for r := 0; r <= rounds; r++ {
+		left, right = affineLayer(s, left, right)
+
+		switch {
+		case r == rounds:
+			// the final affine layer has no S-box
+		case r == rounds-1:
+			left, right = cube(left, p), cube(right, p)
+		default:
+			left, right = feistel(left, p), feistel(right, p)
+		}
+	}
+
+	out := make([]uint64, t)
+	for i := range out {
+		out[i] = left[i].Uint64()
+	}
+	return out
+}
+
+type sampler struct {
+	xof sha3.ShakeHash
+	p   *big.Int
+}
+
+// element squeezes 8-byte big-endian words, keeps the low bit-length-of-p
+// bits and rejects values >= p (and 0 unless allowZero).
+func (s *sampler) element(allowZero bool) *big.Int {
+	bitLen := uint(s.p.BitLen())
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bitLen), big.NewInt(1))
+
+	for {
+		word := make([]byte, 8)
+		s.xof.Read(word)
+
+		e := new(big.Int).And(new(big.Int).SetBytes(word), mask)
+		if e.Sign() == 0 && !allowZero {
+			continue
+		}
+		if e.Cmp(s.p) < 0 {
+			return e
+		}
+	}
+}
+
+func (s *sampler) vector(n int, allowZero bool) []*big.Int {
+	v := make([]*big.Int, n)
+	for i := range v {
+		v[i] = s.element(allowZero)
+	}
+	return v
+}
+
+// matrix builds the t x t matrix whose first row is firstRow and whose row
+// i+1 is row i shifted right by one plus firstRow scaled by the last
+// element of row i.
+func matrix(firstRow []*big.Int, p *big.Int) [][]*big.Int {
+	t := len(firstRow)
+	m := make([][]*big.Int, t)
+	m[0] = firstRow
+
+	for i := 1; i < t; i++ {
+		prev := m[i-1]
+		m[i] = make([]*big.Int, t)
+		for j := 0; j < t; j++ {
+			e := new(big.Int).Mul(firstRow[j], prev[t-1])
+			if j > 0 {
+				e.Add(e, prev[j-1])
+			}
+			m[i][j] = e.Mod(e, p)
+		}
+	}
+
+	return m
+}
+
+// affineLayer samples the matrices of both halves, then their round
+// constants, computes M x + c on each half and mixes the halves.
+func affineLayer(s *sampler, left, right []*big.Int) ([]*big.Int, []*big.Int) {
+	t := len(left)
+
+	m1 := matrix(s.vector(t, false), s.p)
+	m2 := matrix(s.vector(t, false), s.p)
+	c1 := s.vector(t, true)
+	c2 := s.vector(t, true)
+
+	left = affine(m1, left, c1, s.p)
+	right = affine(m2, right, c2, s.p)
+
+	return mix(left, right, s.p)
+}
+
+func affine(m [][]*big.Int, x, c []*big.Int, p *big.Int) []*big.Int {
+	y := make([]*big.Int, len(x))
+	for i := range m {
+		sum := new(big.Int).Set(c[i])
+		for j := range x {
+			sum.Add(sum, new(big.Int).Mul(m[i][j], x[j]))
+		}
+		y[i] = sum.Mod(sum, p)
+	}
+	return y
+}
+
+// mix maps (l, r) to (2l + r, l + 2r).
+func mix(left, right []*big.Int, p *big.Int) ([]*big.Int, []*big.Int) {
+	l := make([]*big.Int, len(left))
+	r := make([]*big.Int, len(right))
+	for i := range left {
+		sum := new(big.Int).Add(left[i], right[i])
+		l[i] = new(big.Int).Add(sum, left[i])
+		l[i].Mod(l[i], p)
+		r[i] = new(big.Int).Add(sum, right[i])
+		r[i].Mod(r[i], p)
+	}
+	return l, r
+}
+
+// cube maps x_i to x_i^3.
+func cube(x []*big.Int, p *big.Int) []*big.Int {
+	y := make([]*big.Int, len(x))
+	for i := range x {
+		y[i] = new(big.Int).Exp(x[i], big.NewInt(3), p)
+	}
+	return y
+}
+
+// feistel maps x_0 to x_0 and x_i to x_i + x_{i-1}^2.
+func feistel(x []*big.Int, p *big.Int) []*big.Int {
+	y := make([]*big.Int, len(x))
+	y[0] = new(big.Int).Set(x[0])
+	for i := 1; i < len(x); i++ {
+		sq := new(big.Int).Mul(x[i-1], x[i-1])
+		y[i] = sq.Add(sq, x[i])
+		y[i].Mod(y[i], p)
+	}
+	return y
+}
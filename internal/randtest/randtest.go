@@ -0,0 +1,248 @@
+// Package randtest runs statistical tests on samples of field elements that
+// should be uniform mod p. Unlike byte-oriented suites the expected
+// distributions account for the modulus, so a packed stream of elements
+// mod a non power of two is not reported as biased.
+package randtest
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+// DefaultAlpha is the significance level of Result.Pass.
+const DefaultAlpha = 1e-3
+
+// Result is the outcome of one test. DF is the degrees of freedom of a
+// chi-square statistic, or 0 for a standard normal one.
+type Result struct {
+	Name      string
+	Statistic float64
+	DF        int
+	PValue    float64
+}
+
+func (r Result) Pass(alpha float64) bool {
+	return r.PValue >= alpha
+}
+
+func (r Result) String() string {
+	kind := "z"
+	if r.DF > 0 {
+		kind = fmt.Sprintf("chi2(%d)", r.DF)
+	}
+	return fmt.Sprintf("%-18s %s = %.4f, p = %.4f", r.Name, kind, r.Statistic, r.PValue)
+}
+
+// Suite runs every test on samples.
+func Suite(samples []uint64, modulus uint64) []Result {
+	return []Result{
+		Uniformity(samples, modulus),
+		SerialCorrelation(samples, modulus),
+		Runs(samples, modulus),
+		BitFrequency(samples, modulus),
+	}
+}
+
+// maxClasses bounds the number of residue classes of Uniformity.
+const maxClasses = 256
+
+// Uniformity is a chi-square test of the counts of min(p, 256) residue
+// classes, class c holding the elements e with floor(e*k/p) = c. Classes
+// differ in size by one element at most and are weighted exactly.
+func Uniformity(samples []uint64, modulus uint64) Result {
+	k := uint64(maxClasses)
+	if modulus < k {
+		k = modulus
+	}
+
+	counts := make([]float64, k)
+	for _, e := range samples {
+		hi, lo := bits.Mul64(e, k)
+		c, _ := bits.Div64(hi, lo, modulus)
+		counts[c]++
+	}
+
+	p := new(big.Int).SetUint64(modulus)
+	bigK := new(big.Int).SetUint64(k)
+	start := func(c uint64) *big.Int {
+		// ceil(c*p/k)
+		n := new(big.Int).Mul(new(big.Int).SetUint64(c), p)
+		n.Add(n, bigK).Sub(n, big.NewInt(1))
+		return n.Quo(n, bigK)
+	}
+
+	n := float64(len(samples))
+	chi2 := 0.0
+	for c := uint64(0); c < k; c++ {
+		size := new(big.Int).Sub(start(c+1), start(c))
+		sizeF, _ := new(big.Float).SetInt(size).Float64()
+		expected := n * sizeF / float64(modulus)
+		d := counts[c] - expected
+		chi2 += d * d / expected
+	}
+
+	df := int(k) - 1
+	return Result{"uniformity", chi2, df, chiSquareSF(chi2, df)}
+}
+
+// SerialCorrelation is the correlation of consecutive samples scaled by
+// sqrt(n-1), asymptotically standard normal.
+func SerialCorrelation(samples []uint64, modulus uint64) Result {
+	n := len(samples) - 1
+	if n < 2 {
+		return Result{"serial correlation", 0, 0, 1}
+	}
+
+	var sx, sy, sxx, syy, sxy float64
+	for i := 0; i < n; i++ {
+		x := float64(samples[i]) / float64(modulus)
+		y := float64(samples[i+1]) / float64(modulus)
+		sx += x
+		sy += y
+		sxx += x * x
+		syy += y * y
+		sxy += x * y
+	}
+
+	fn := float64(n)
+	r := (fn*sxy - sx*sy) / math.Sqrt((fn*sxx-sx*sx)*(fn*syy-sy*sy))
+	z := r * math.Sqrt(fn)
+
+	return Result{"serial correlation", z, 0, normalTwoSided(z)}
+}
+
+// Runs counts the runs of samples below and at or above ceil(p/2). For a
+// probability q of being below, the number of runs of n samples has mean
+// 1 + (n-1)s and variance (n-1)s(1-s) + (n-2)(s - 2s^2), s = 2q(1-q).
+func Runs(samples []uint64, modulus uint64) Result {
+	n := len(samples)
+	if n < 3 {
+		return Result{"runs", 0, 0, 1}
+	}
+
+	half := modulus/2 + modulus%2
+	runs := 1
+	for i := 1; i < n; i++ {
+		if (samples[i] < half) != (samples[i-1] < half) {
+			runs++
+		}
+	}
+
+	q := float64(half) / float64(modulus)
+	s := 2 * q * (1 - q)
+	fn := float64(n)
+	mean := 1 + (fn-1)*s
+	variance := (fn-1)*s*(1-s) + (fn-2)*(s-2*s*s)
+
+	z := (float64(runs) - mean) / math.Sqrt(variance)
+	return Result{"runs", z, 0, normalTwoSided(z)}
+}
+
+// BitFrequency is a chi-square test of the number of ones at every bit
+// position of the elements packed to the width of p, against the exact
+// probability of a uniform element mod p having that bit set. The
+// positions of an element are not independent for p not a power of two,
+// so the statistic is approximate.
+func BitFrequency(samples []uint64, modulus uint64) Result {
+	width := bits.Len64(modulus - 1)
+
+	ones := make([]float64, width)
+	for _, e := range samples {
+		for b := 0; b < width; b++ {
+			ones[b] += float64(e >> uint(b) & 1)
+		}
+	}
+
+	n := float64(len(samples))
+	chi2 := 0.0
+	df := 0
+	for b := 0; b < width; b++ {
+		q := bitProbability(modulus, b)
+		if q == 0 || q == 1 {
+			continue
+		}
+		d := ones[b] - n*q
+		chi2 += d * d / (n * q * (1 - q))
+		df++
+	}
+	if df == 0 {
+		return Result{"bit frequency", 0, 0, 1}
+	}
+
+	return Result{"bit frequency", chi2, df, chiSquareSF(chi2, df)}
+}
+
+// bitProbability is the fraction of [0, p) with bit b set.
+func bitProbability(modulus uint64, b int) float64 {
+	period := new(big.Int).Lsh(big.NewInt(1), uint(b+1))
+	p := new(big.Int).SetUint64(modulus)
+
+	full, rest := new(big.Int).QuoRem(p, period, new(big.Int))
+	count := new(big.Int).Lsh(full, uint(b))
+	if extra := rest.Sub(rest, new(big.Int).Lsh(big.NewInt(1), uint(b))); extra.Sign() > 0 {
+		count.Add(count, extra)
+	}
+
+	f, _ := new(big.Rat).SetFrac(count, p).Float64()
+	return f
+}
+
+func normalTwoSided(z float64) float64 {
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// chiSquareSF is the upper tail of the chi-square distribution with df
+// degrees of freedom, Q(df/2, x/2).
+func chiSquareSF(x float64, df int) float64 {
+	if x <= 0 {
+		return 1
+	}
+	return upperGamma(float64(df)/2, x/2)
+}
+
+// upperGamma is the regularized upper incomplete gamma function, by its
+// series below a+1 and its continued fraction above (Numerical Recipes).
+func upperGamma(a, x float64) float64 {
+	lg, _ := math.Lgamma(a)
+	prefix := math.Exp(-x + a*math.Log(x) - lg)
+
+	if x < a+1 {
+		sum, term := 1/a, 1/a
+		for n := 1; n < 1000; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if term < sum*1e-15 {
+				break
+			}
+		}
+		return 1 - sum*prefix
+	}
+
+	// modified Lentz
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < 1000; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return prefix * h
+}
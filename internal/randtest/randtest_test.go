@@ -0,0 +1,75 @@
+package randtest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestChiSquareSF(t *testing.T) {
+	for _, c := range []struct {
+		x    float64
+		df   int
+		want float64
+	}{
+		{3.841459, 1, 0.05},
+		{18.307038, 10, 0.05},
+		{10.850811, 20, 0.95},
+		{310.457388, 255, 0.01},
+	} {
+		if got := chiSquareSF(c.x, c.df); math.Abs(got-c.want) > 1e-5 {
+			t.Errorf("Q(%v; %d) = %v, want %v", c.x, c.df, got, c.want)
+		}
+	}
+}
+
+func TestBitProbability(t *testing.T) {
+	// 0..4 = 000 001 010 011 100
+	for b, want := range []float64{2.0 / 5, 2.0 / 5, 1.0 / 5} {
+		if got := bitProbability(5, b); got != want {
+			t.Errorf("bit %d: %v, want %v", b, got, want)
+		}
+	}
+}
+
+func TestSuite(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, modulus := range []uint64{7, 65537, 8088322049, 1096486890805657601} {
+		samples := make([]uint64, 1<<16)
+		for i := range samples {
+			samples[i] = uint64(rng.Int63n(int64(modulus)))
+		}
+		for _, r := range Suite(samples, modulus) {
+			if !r.Pass(DefaultAlpha) {
+				t.Errorf("p=%d: uniform samples fail %v", modulus, r)
+			}
+		}
+	}
+}
+
+func TestSuiteDetectsBias(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	modulus := uint64(65537)
+	samples := make([]uint64, 1<<16)
+
+	// an eighth of the lower half moves to the upper half
+	for i := range samples {
+		samples[i] = uint64(rng.Intn(1 << 16))
+		if samples[i] < 1<<15 && rng.Intn(8) == 0 {
+			samples[i] += 1 << 15
+		}
+	}
+	if r := Uniformity(samples, modulus); r.Pass(DefaultAlpha) {
+		t.Errorf("biased samples pass %v", r)
+	}
+
+	// a random walk is correlated
+	walk := make([]uint64, 1<<14)
+	for i := 1; i < len(walk); i++ {
+		walk[i] = (walk[i-1] + uint64(rng.Intn(1000))) % modulus
+	}
+	if r := SerialCorrelation(walk, modulus); r.Pass(DefaultAlpha) {
+		t.Errorf("random walk passes %v", r)
+	}
+}
@@ -0,0 +1,428 @@
+package pasta
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// KAT is a known-answer test for one keystream block: the keystream of
+// block BlockCounter under Key and Nonce, the state halves at the end of
+// every round, and a plaintext block with its ciphertext.
+type KAT struct {
+	// Cipher is a registered cipher name such as "hera5", or empty for
+	// PASTA. Round states are only recorded for PASTA.
+	Cipher string
+
+	Modulus      uint64
+	Params       Params
+	Key          []uint64
+	Nonce        uint64
+	BlockCounter uint64
+
+	Rounds    []RoundState
+	Keystream []uint64
+
+	Plaintext  []uint64
+	Ciphertext []uint64
+}
+
+// RoundState is the state after the S-box of a round.
+type RoundState struct {
+	State1, State2 []uint64
+}
+
+// NewKAT computes the known answers of key for plaintext, a block of at
+// most PlainSize elements.
+func NewKAT(key []uint64, modulus uint64, params Params, nonce, blockCounter uint64, plaintext []uint64) *KAT {
+	util := NewUtil(key, modulus, int(params.Rounds))
+
+	rounds := make([]RoundState, 0, params.Rounds)
+	util.SetTracer(func(e TraceEvent) {
+		// the S-box of the second half ends a round
+		if e.Step == StepSBox && e.Half == 2 {
+			rounds = append(rounds, RoundState{
+				append([]uint64(nil), e.State1...),
+				append([]uint64(nil), e.State2...),
+			})
+		}
+	})
+
+	keystream := util.Keystream(nonce, blockCounter)
+
+	ciphertext := make([]uint64, len(plaintext))
+	for i := range plaintext {
+		ciphertext[i] = addMod(plaintext[i], keystream[i], modulus)
+	}
+
+	return &KAT{
+		"",
+		modulus,
+		params,
+		append([]uint64(nil), key...),
+		nonce,
+		blockCounter,
+		rounds,
+		keystream[:params.PlainSize],
+		append([]uint64(nil), plaintext...),
+		ciphertext,
+	}
+}
+
+// GenerateKAT is NewKAT for a key and a full plaintext block sampled from
+// rand.
+func GenerateKAT(rand io.Reader, modulus uint64, params Params, nonce, blockCounter uint64) (*KAT, error) {
+	key, err := GenerateKey(rand, modulus, params)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := sampleElements(rand, int(params.PlainSize), modulus)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKAT(key.Elements, modulus, params, nonce, blockCounter, plaintext), nil
+}
+
+// Verify recomputes k with Util, and with Pasta for block 0, reporting the
+// first mismatching answer. Round states and keystream may be left out, so
+// that vectors publishing only a message and its ciphertext can be replayed;
+// such a message may span several blocks if it starts at block 0. Vectors
+// of other ciphers are recomputed with the cipher registered as k.Cipher;
+// Rubato ciphertexts only have to decrypt to the plaintext up to the noise.
+func (k *KAT) Verify() error {
+	if k.Cipher != "" && !strings.HasPrefix(k.Cipher, "pasta") {
+		return k.verifyCipher()
+	}
+	if err := validateHeader(k.Modulus, k.Params); err != nil {
+		return err
+	}
+	if uint64(len(k.Key)) != k.Params.SecretKeySize {
+		return fmt.Errorf("key has %d elements, expected %d", len(k.Key), k.Params.SecretKeySize)
+	}
+	if len(k.Ciphertext) != len(k.Plaintext) {
+		return fmt.Errorf("invalid plaintext/ciphertext sizes %d/%d", len(k.Plaintext), len(k.Ciphertext))
+	}
+
+	block := k.Plaintext
+	if uint64(len(block)) > k.Params.PlainSize {
+		if k.BlockCounter != 0 {
+			return fmt.Errorf("a message of several blocks must start at block 0")
+		}
+		block = block[:k.Params.PlainSize]
+	}
+
+	want := NewKAT(k.Key, k.Modulus, k.Params, k.Nonce, k.BlockCounter, block)
+
+	if len(k.Rounds) > 0 {
+		if len(k.Rounds) != len(want.Rounds) {
+			return fmt.Errorf("%d round states, expected %d", len(k.Rounds), len(want.Rounds))
+		}
+		for r := range want.Rounds {
+			if !equalElements(k.Rounds[r].State1, want.Rounds[r].State1) {
+				return fmt.Errorf("round %d: state1 mismatch", r)
+			}
+			if !equalElements(k.Rounds[r].State2, want.Rounds[r].State2) {
+				return fmt.Errorf("round %d: state2 mismatch", r)
+			}
+		}
+	}
+	if len(k.Keystream) > 0 && !equalElements(k.Keystream, want.Keystream) {
+		return fmt.Errorf("keystream mismatch")
+	}
+	if !equalElements(k.Ciphertext[:len(block)], want.Ciphertext) {
+		return fmt.Errorf("ciphertext mismatch")
+	}
+
+	if k.BlockCounter == 0 {
+		pasta := NewPasta(k.Key, k.Modulus, k.Params)
+		if !equalElements(pasta.EncryptWithNonce(k.Plaintext, k.Nonce), k.Ciphertext) {
+			return fmt.Errorf("Pasta.EncryptWithNonce mismatch")
+		}
+		if !equalElements(pasta.DecryptWithNonce(k.Ciphertext, k.Nonce), k.Plaintext) {
+			return fmt.Errorf("Pasta.DecryptWithNonce mismatch")
+		}
+	}
+
+	return nil
+}
+
+// verifyCipher is Verify for a registered cipher other than PASTA: the
+// keystream of the block and the ciphertext.
+func (k *KAT) verifyCipher() error {
+	if len(k.Rounds) > 0 {
+		return fmt.Errorf("%s: round states are only defined for PASTA", k.Cipher)
+	}
+	cipher, err := NewCipherFromConfig(CipherConfig{k.Cipher, k.Modulus, &k.Params}, k.Key)
+	if err != nil {
+		return err
+	}
+	if len(k.Ciphertext) != len(k.Plaintext) {
+		return fmt.Errorf("invalid plaintext/ciphertext sizes %d/%d", len(k.Plaintext), len(k.Ciphertext))
+	}
+	if uint64(len(k.Plaintext)) > k.Params.PlainSize && k.BlockCounter != 0 {
+		return fmt.Errorf("a message of several blocks must start at block 0")
+	}
+
+	keystream := cipher.Keystream(k.Nonce, k.BlockCounter)
+	if uint64(len(keystream)) < k.Params.PlainSize {
+		return fmt.Errorf("%s: keystream of %d elements", k.Cipher, len(keystream))
+	}
+	if len(k.Keystream) > 0 && !equalElements(k.Keystream, keystream[:k.Params.PlainSize]) {
+		return fmt.Errorf("keystream mismatch")
+	}
+
+	if rubato, ok := cipher.(*Rubato); ok {
+		return k.verifyNoisy(rubato, keystream)
+	}
+
+	var ciphertext []uint64
+	if k.BlockCounter == 0 {
+		ciphertext = cipher.EncryptWithNonce(k.Plaintext, k.Nonce)
+	} else {
+		ciphertext = make([]uint64, len(k.Plaintext))
+		for i := range k.Plaintext {
+			ciphertext[i] = addMod(k.Plaintext[i], keystream[i], k.Modulus)
+		}
+	}
+	if !equalElements(ciphertext, k.Ciphertext) {
+		return fmt.Errorf("ciphertext mismatch")
+	}
+	if k.BlockCounter == 0 && !equalElements(cipher.DecryptWithNonce(k.Ciphertext, k.Nonce), k.Plaintext) {
+		return fmt.Errorf("%s: DecryptWithNonce mismatch", k.Cipher)
+	}
+
+	return nil
+}
+
+// verifyNoisy checks a Rubato ciphertext, which carries random noise: it
+// must decrypt to the plaintext up to 6 sigma.
+func (k *KAT) verifyNoisy(rubato *Rubato, keystream []uint64) error {
+	decrypted := make([]uint64, len(k.Ciphertext))
+	if k.BlockCounter == 0 {
+		decrypted = rubato.DecryptWithNonce(k.Ciphertext, k.Nonce)
+	} else {
+		for i := range k.Ciphertext {
+			decrypted[i] = subMod(k.Ciphertext[i]%k.Modulus, keystream[i], k.Modulus)
+		}
+	}
+
+	bound := uint64(math.Ceil(6 * rubato.CipherParams.Sigma))
+	for i := range decrypted {
+		e := subMod(decrypted[i], k.Plaintext[i]%k.Modulus, k.Modulus)
+		if e > bound && k.Modulus-e > bound {
+			return fmt.Errorf("ciphertext mismatch: element %d is off by more than %d", i, bound)
+		}
+	}
+	return nil
+}
+
+func equalElements(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// KAT files are line based. Every vector is a run of "name = value" lines
+// ended by a blank line; vectors are numbered by "count" and element lists
+// are space-separated decimals. Lines starting with '#' are comments. The
+// round states and keystream are optional. Vectors of another registered
+// cipher start with a line such as "cipher = hera5" after count and have
+// no round states.
+//
+//	count = 0
+//	modulus = 65537
+//	params = 64 32 32 4
+//	key = ...
+//	nonce = 123456789
+//	block = 0
+//	round0.state1 = ...
+//	round0.state2 = ...
+//	...
+//	keystream = ...
+//	plaintext = ...
+//	ciphertext = ...
+
+func WriteKATs(w io.Writer, kats []*KAT) error {
+	bw := bufio.NewWriter(w)
+
+	for i, k := range kats {
+		fmt.Fprintf(bw, "count = %d\n", i)
+		if k.Cipher != "" {
+			fmt.Fprintf(bw, "cipher = %s\n", k.Cipher)
+		}
+		fmt.Fprintf(bw, "modulus = %d\n", k.Modulus)
+		fmt.Fprintf(bw, "params = %d %d %d %d\n",
+			k.Params.SecretKeySize, k.Params.PlainSize, k.Params.CipherSize, k.Params.Rounds)
+		writeList(bw, "key", k.Key)
+		fmt.Fprintf(bw, "nonce = %d\n", k.Nonce)
+		fmt.Fprintf(bw, "block = %d\n", k.BlockCounter)
+		for r, state := range k.Rounds {
+			writeList(bw, fmt.Sprintf("round%d.state1", r), state.State1)
+			writeList(bw, fmt.Sprintf("round%d.state2", r), state.State2)
+		}
+		if len(k.Keystream) > 0 {
+			writeList(bw, "keystream", k.Keystream)
+		}
+		writeList(bw, "plaintext", k.Plaintext)
+		writeList(bw, "ciphertext", k.Ciphertext)
+		fmt.Fprintln(bw)
+	}
+
+	return bw.Flush()
+}
+
+func writeList(w *bufio.Writer, name string, elements []uint64) {
+	w.WriteString(name)
+	w.WriteString(" =")
+	for _, e := range elements {
+		w.WriteByte(' ')
+		w.WriteString(strconv.FormatUint(e, 10))
+	}
+	w.WriteByte('\n')
+}
+
+func ReadKATs(r io.Reader) ([]*KAT, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+
+	var kats []*KAT
+	var current *KAT
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "#") {
+			continue
+		}
+		if text == "" {
+			current = nil
+			continue
+		}
+
+		name, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected name = value", line)
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+
+		if current == nil {
+			if name != "count" {
+				return nil, fmt.Errorf("line %d: vector does not start with count", line)
+			}
+			current = &KAT{}
+			kats = append(kats, current)
+			continue
+		}
+
+		if err := current.set(name, value); err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", line, name, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return kats, nil
+}
+
+// ReadKATFile reads the vectors of the KAT file at path.
+func ReadKATFile(path string) ([]*KAT, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	kats, err := ReadKATs(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return kats, nil
+}
+
+func (k *KAT) set(name, value string) error {
+	if name == "cipher" {
+		k.Cipher = value
+		return nil
+	}
+
+	elements, err := parseList(value)
+	if err != nil {
+		return err
+	}
+
+	single := func(dst *uint64) error {
+		if len(elements) != 1 {
+			return fmt.Errorf("expected one value")
+		}
+		*dst = elements[0]
+		return nil
+	}
+
+	switch name {
+	case "modulus":
+		return single(&k.Modulus)
+	case "params":
+		if len(elements) != 4 || elements[3] > maxRounds {
+			return fmt.Errorf("expected secret key size, plain size, cipher size and rounds")
+		}
+		k.Params = Params{elements[0], elements[1], elements[2], uint(elements[3])}
+	case "key":
+		k.Key = elements
+	case "nonce":
+		return single(&k.Nonce)
+	case "block":
+		return single(&k.BlockCounter)
+	case "keystream":
+		k.Keystream = elements
+	case "plaintext":
+		k.Plaintext = elements
+	case "ciphertext":
+		k.Ciphertext = elements
+	default:
+		var r int
+		var half string
+		if _, err := fmt.Sscanf(name, "round%d.%s", &r, &half); err != nil || r != len(k.Rounds) && r != len(k.Rounds)-1 {
+			return fmt.Errorf("unknown field")
+		}
+		if r == len(k.Rounds) {
+			k.Rounds = append(k.Rounds, RoundState{})
+		}
+		switch half {
+		case "state1":
+			k.Rounds[r].State1 = elements
+		case "state2":
+			k.Rounds[r].State2 = elements
+		default:
+			return fmt.Errorf("unknown field")
+		}
+	}
+
+	return nil
+}
+
+func parseList(value string) ([]uint64, error) {
+	fields := strings.Fields(value)
+	elements := make([]uint64, len(fields))
+	for i, field := range fields {
+		e, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = e
+	}
+	return elements, nil
+}
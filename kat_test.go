@@ -0,0 +1,199 @@
+package pasta
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKATRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+
+	var kats []*KAT
+	for _, modulus := range []uint64{17, 65537, 8088322049} {
+		for _, params := range []Params{Pasta3, Pasta4} {
+			kat, err := GenerateKAT(rng, modulus, params, DefaultNonce, uint64(len(kats)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(kat.Rounds) != int(params.Rounds) {
+				t.Fatalf("%d round states, want %d", len(kat.Rounds), params.Rounds)
+			}
+			kats = append(kats, kat)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteKATs(&buf, kats); err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+
+	decoded, err := ReadKATs(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(kats) {
+		t.Fatalf("read %d vectors, want %d", len(decoded), len(kats))
+	}
+	for i, kat := range decoded {
+		if err := kat.Verify(); err != nil {
+			t.Errorf("vector %d: %v", i, err)
+		}
+	}
+
+	// the format is stable: writing what was read gives the same text
+	buf.Reset()
+	if err := WriteKATs(&buf, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != text {
+		t.Error("rewritten vectors differ")
+	}
+}
+
+func TestKATVerifyMismatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	kat, err := GenerateKAT(rng, 65537, Pasta4, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kat.Rounds[1].State2[5] ^= 1
+	if err := kat.Verify(); err == nil || !strings.Contains(err.Error(), "round 1: state2") {
+		t.Errorf("got %v, want a round 1 state2 mismatch", err)
+	}
+}
+
+// TestCipherKATVerify checks Verify on vectors of another cipher. They are
+// computed by this package, so only the verifier is tested here.
+func TestCipherKATVerify(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	modulus := uint64(65537)
+
+	var kats []*KAT
+	for _, name := range []string{"hera4", "hera5", "masta4", "rubato80s", "rubato128m"} {
+		params, _ := DefaultParams(name)
+		key := randomVector(rng, int(params.SecretKeySize), modulus)
+		cipher, err := NewCipher(name, key, modulus)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		plaintext := randomVector(rng, 2*int(params.PlainSize)+1, modulus)
+		kats = append(kats, &KAT{
+			name, modulus, params, key, 7, 0, nil,
+			cipher.Keystream(7, 0)[:params.PlainSize],
+			plaintext, cipher.EncryptWithNonce(plaintext, 7),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := WriteKATs(&buf, kats); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadKATs(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, kat := range decoded {
+		if kat.Cipher != kats[i].Cipher {
+			t.Errorf("vector %d: cipher %q, want %q", i, kat.Cipher, kats[i].Cipher)
+		}
+		if err := kat.Verify(); err != nil {
+			t.Errorf("%s: %v", kat.Cipher, err)
+		}
+
+		kat.Keystream[3] ^= 1
+		if err := kat.Verify(); err == nil || !strings.Contains(err.Error(), "keystream") {
+			t.Errorf("%s: got %v, want a keystream mismatch", kat.Cipher, err)
+		}
+		kat.Keystream = nil
+		last := len(kat.Ciphertext) - 1
+		kat.Ciphertext[last] = addMod(kat.Ciphertext[last], modulus/2, modulus)
+		if err := kat.Verify(); err == nil || !strings.Contains(err.Error(), "ciphertext") {
+			t.Errorf("%s: got %v, want a ciphertext mismatch", kat.Cipher, err)
+		}
+	}
+}
+
+// TestReferenceVectors reports the ciphers without vectors of their
+// reference implementation in testdata/kat/*_reference.txt. Vectors
+// generated by this package only catch regressions, not a misreading of
+// the specification.
+func TestReferenceVectors(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "kat", "*_reference.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	covered := make(map[string]bool)
+	for _, file := range files {
+		kats, err := ReadKATFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, kat := range kats {
+			name := kat.Cipher
+			if name == "" {
+				name = fmt.Sprintf("pasta%d", kat.Params.Rounds)
+			}
+			covered[strings.TrimRight(name, "0123456789")] = true
+			covered[name] = true
+		}
+	}
+
+	var missing []string
+	for _, name := range []string{"pasta3", "pasta4", "hera", "rubato"} {
+		if !covered[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		t.Skipf("no reference vectors for %s", strings.Join(missing, ", "))
+	}
+}
+
+// TestKnownAnswerFiles replays testdata/kat. pasta3_reference.txt holds
+// the vectors of the C++ reference implementation, the other files
+// regression vectors with round states for PASTA-3 and PASTA-4 at 17, 33
+// and 60 bits. Conformance is tracked by TestReferenceVectors.
+func TestKnownAnswerFiles(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "kat", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	covered := make(map[string]bool)
+	for _, file := range files {
+		kats, err := ReadKATFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(kats) == 0 {
+			t.Errorf("%s: no vectors", file)
+		}
+
+		for i, kat := range kats {
+			kat := kat
+			t.Run(fmt.Sprintf("%s/%d", filepath.Base(file), i), func(t *testing.T) {
+				if err := kat.Verify(); err != nil {
+					t.Error(err)
+				}
+			})
+			covered[fmt.Sprintf("%d/%d", kat.Params.Rounds, bits.Len64(kat.Modulus))] = true
+		}
+	}
+
+	for _, rounds := range []int{3, 4} {
+		for _, size := range []int{17, 33, 60} {
+			if !covered[fmt.Sprintf("%d/%d", rounds, size)] {
+				t.Errorf("no regression vectors for PASTA-%d at %d bits", rounds, size)
+			}
+		}
+	}
+}
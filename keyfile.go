@@ -0,0 +1,232 @@
+package pasta
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// Key and container files share a fixed header followed by packed elements:
+//
+//	magic [4]byte | version uint8 | modulus uint64 |
+//	secret key size, plain size, cipher size, rounds uint64 | ...
+//
+// A key file then holds the SecretKeySize packed key elements. A container
+// holds the nonce, the element count and the packed ciphertext.
+const (
+	keyMagic       = "PSTK"
+	containerMagic = "PSTC"
+	formatVersion  = 1
+
+	headerSize = 4 + 1 + 5*8
+)
+
+var (
+	ErrBadMagic   = errors.New("pasta: unrecognized file format")
+	ErrBadVersion = errors.New("pasta: unsupported format version")
+	ErrTruncated  = errors.New("pasta: truncated input")
+)
+
+// Key is a PASTA secret key together with the parameters it was made for.
+type Key struct {
+	Modulus  uint64
+	Params   Params
+	Elements []uint64
+}
+
+// GenerateKey samples a uniform secret key mod modulus from rand.
+func GenerateKey(rand io.Reader, modulus uint64, params Params) (*Key, error) {
+	if err := validateHeader(modulus, params); err != nil {
+		return nil, err
+	}
+
+	elements, err := sampleElements(rand, int(params.SecretKeySize), modulus)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{modulus, params, elements}, nil
+}
+
+// sampleElements rejection-samples n uniform elements mod modulus.
+func sampleElements(rand io.Reader, n int, modulus uint64) ([]uint64, error) {
+	mask := uint64(1)<<uint(bits.Len64(modulus-1)) - 1
+
+	elements := make([]uint64, n)
+	var word [8]byte
+	for i := range elements {
+		for {
+			if _, err := io.ReadFull(rand, word[:]); err != nil {
+				return nil, err
+			}
+			e := binary.BigEndian.Uint64(word[:]) & mask
+			if e < modulus {
+				elements[i] = e
+				break
+			}
+		}
+	}
+
+	return elements, nil
+}
+
+// Pasta returns the cipher keyed by k.
+func (k *Key) Pasta() Pasta {
+	return NewPasta(k.Elements, k.Modulus, k.Params)
+}
+
+func (k *Key) MarshalBinary() ([]byte, error) {
+	if err := validateHeader(k.Modulus, k.Params); err != nil {
+		return nil, err
+	}
+	if uint64(len(k.Elements)) != k.Params.SecretKeySize {
+		return nil, fmt.Errorf("pasta: key has %d elements, expected %d",
+			len(k.Elements), k.Params.SecretKeySize)
+	}
+	if err := checkReduced(k.Elements, k.Modulus); err != nil {
+		return nil, err
+	}
+
+	out := appendHeader(nil, keyMagic, k.Modulus, k.Params)
+	return append(out, PackElements(k.Elements, k.Modulus)...), nil
+}
+
+func (k *Key) UnmarshalBinary(data []byte) error {
+	modulus, params, rest, err := parseHeader(data, keyMagic)
+	if err != nil {
+		return err
+	}
+	if params.SecretKeySize > uint64(len(rest))*8 {
+		return ErrTruncated
+	}
+
+	elements, err := UnpackElements(rest, int(params.SecretKeySize), modulus)
+	if err != nil {
+		return err
+	}
+
+	*k = Key{modulus, params, elements}
+	return nil
+}
+
+// Container is a ciphertext with everything but the key needed to decrypt it.
+type Container struct {
+	Modulus    uint64
+	Params     Params
+	Nonce      uint64
+	Ciphertext []uint64
+}
+
+// Seal encrypts plaintext under nonce into a Container.
+func (k *Key) Seal(plaintext []uint64, nonce uint64) *Container {
+	pasta := k.Pasta()
+	return &Container{k.Modulus, k.Params, nonce, pasta.EncryptWithNonce(plaintext, nonce)}
+}
+
+// Open decrypts c, which must have been sealed under the parameters of k.
+func (k *Key) Open(c *Container) ([]uint64, error) {
+	if c.Modulus != k.Modulus || c.Params != k.Params {
+		return nil, errors.New("pasta: container parameters do not match the key")
+	}
+
+	pasta := k.Pasta()
+	return pasta.DecryptWithNonce(c.Ciphertext, c.Nonce), nil
+}
+
+func (c *Container) MarshalBinary() ([]byte, error) {
+	if err := validateHeader(c.Modulus, c.Params); err != nil {
+		return nil, err
+	}
+	if err := checkReduced(c.Ciphertext, c.Modulus); err != nil {
+		return nil, err
+	}
+
+	out := appendHeader(nil, containerMagic, c.Modulus, c.Params)
+	out = binary.BigEndian.AppendUint64(out, c.Nonce)
+	out = binary.BigEndian.AppendUint64(out, uint64(len(c.Ciphertext)))
+	return append(out, PackElements(c.Ciphertext, c.Modulus)...), nil
+}
+
+func (c *Container) UnmarshalBinary(data []byte) error {
+	modulus, params, rest, err := parseHeader(data, containerMagic)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 16 {
+		return ErrTruncated
+	}
+
+	nonce := binary.BigEndian.Uint64(rest)
+	count := binary.BigEndian.Uint64(rest[8:])
+	rest = rest[16:]
+
+	// bound count by the payload before allocating
+	if count > uint64(len(rest))*8 {
+		return ErrTruncated
+	}
+
+	ciphertext, err := UnpackElements(rest, int(count), modulus)
+	if err != nil {
+		return err
+	}
+
+	*c = Container{modulus, params, nonce, ciphertext}
+	return nil
+}
+
+func appendHeader(out []byte, magic string, modulus uint64, params Params) []byte {
+	out = append(out, magic...)
+	out = append(out, formatVersion)
+	out = binary.BigEndian.AppendUint64(out, modulus)
+	out = binary.BigEndian.AppendUint64(out, params.SecretKeySize)
+	out = binary.BigEndian.AppendUint64(out, params.PlainSize)
+	out = binary.BigEndian.AppendUint64(out, params.CipherSize)
+	return binary.BigEndian.AppendUint64(out, uint64(params.Rounds))
+}
+
+func parseHeader(data []byte, magic string) (uint64, Params, []byte, error) {
+	if len(data) < 4 || string(data[:4]) != magic {
+		return 0, Params{}, nil, ErrBadMagic
+	}
+	if len(data) < headerSize {
+		return 0, Params{}, nil, ErrTruncated
+	}
+	if data[4] != formatVersion {
+		return 0, Params{}, nil, ErrBadVersion
+	}
+
+	word := func(i int) uint64 { return binary.BigEndian.Uint64(data[5+8*i:]) }
+
+	modulus := word(0)
+	if word(4) > maxRounds {
+		return 0, Params{}, nil, fmt.Errorf("pasta: invalid number of rounds %d", word(4))
+	}
+	params := Params{word(1), word(2), word(3), uint(word(4))}
+
+	if err := validateHeader(modulus, params); err != nil {
+		return 0, Params{}, nil, err
+	}
+
+	return modulus, params, data[headerSize:], nil
+}
+
+func validateHeader(modulus uint64, params Params) error {
+	if modulus < 2 {
+		return fmt.Errorf("pasta: invalid modulus %d", modulus)
+	}
+	if err := validatePastaParams(params); err != nil {
+		return fmt.Errorf("pasta: %w", err)
+	}
+	return nil
+}
+
+func checkReduced(elements []uint64, modulus uint64) error {
+	for i, e := range elements {
+		if e >= modulus {
+			return fmt.Errorf("pasta: element %d: %d is not reduced mod %d", i, e, modulus)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package pasta
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestElementFormats(t *testing.T) {
+	elements := []uint64{0, 1, 65536, 1<<60 - 1}
+
+	for _, format := range []ElementFormat{FormatDecimal, FormatHex, FormatBinary} {
+		var buf bytes.Buffer
+		if err := WriteElements(&buf, elements, format); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ReadElements(&buf, format)
+		if err != nil {
+			t.Fatalf("%v: %v", format, err)
+		}
+		if !equalSlices(got, elements) {
+			t.Errorf("%v: got %v, want %v", format, got, elements)
+		}
+	}
+
+	got, err := ReadElements(bytes.NewBufferString("0x10, ff\n0X1"), FormatHex)
+	if err != nil || !equalSlices(got, []uint64{16, 255, 1}) {
+		t.Errorf("hex tokens: got %v, %v", got, err)
+	}
+	if _, err := ReadElements(bytes.NewBufferString("1 2 x"), FormatDecimal); err == nil {
+		t.Error("expected an error for a non-numeric token")
+	}
+}
+
+func TestPackElements(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, modulus := range []uint64{2, 7, 65537, 8088322049, 1096486890805657601, 1<<64 - 59} {
+		for _, n := range []int{0, 1, 3, 17} {
+			elements := randomVector(rng, n, modulus)
+
+			packed := PackElements(elements, modulus)
+			if len(packed) != PackedSize(n, modulus) {
+				t.Fatalf("p=%d n=%d: packed %d bytes", modulus, n, len(packed))
+			}
+
+			got, err := UnpackElements(packed, n, modulus)
+			if err != nil {
+				t.Fatalf("p=%d n=%d: %v", modulus, n, err)
+			}
+			if !equalSlices(got, elements) {
+				t.Errorf("p=%d n=%d: round trip mismatch", modulus, n)
+			}
+		}
+	}
+
+	// 7 is a 3-bit element, so 0b111 is not reduced
+	if _, err := UnpackElements([]byte{0xe0}, 1, 7); err == nil {
+		t.Error("expected an error for an unreduced element")
+	}
+	if _, err := UnpackElements([]byte{0x01}, 1, 7); err == nil {
+		t.Error("expected an error for non-zero padding")
+	}
+}
+
+func TestKeyContainerRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+
+	key, err := GenerateKey(rng, 65537, Pasta4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := key.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Key
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Modulus != key.Modulus || decoded.Params != key.Params ||
+		!equalSlices(decoded.Elements, key.Elements) {
+		t.Fatal("key round trip mismatch")
+	}
+
+	plaintext := randomVector(rng, 45, 65537)
+	sealed, err := key.Seal(plaintext, 42).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var container Container
+	if err := container.UnmarshalBinary(sealed); err != nil {
+		t.Fatal(err)
+	}
+	if container.Nonce != 42 {
+		t.Errorf("nonce %d, want 42", container.Nonce)
+	}
+
+	got, err := decoded.Open(&container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalSlices(got, plaintext) {
+		t.Error("decrypted container differs from plaintext")
+	}
+
+	if err := container.UnmarshalBinary(data); !errors.Is(err, ErrBadMagic) {
+		t.Errorf("key as container: %v", err)
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("expected an error for a truncated key")
+	}
+}
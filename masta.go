@@ -0,0 +1,53 @@
+package pasta
+
+var (
+	Masta4 = Params{64, 64, 64, 4}
+	Masta5 = Params{128, 128, 128, 5}
+)
+
+type Masta struct {
+	SecretKey    SecretKey
+	Modulus      uint64
+	CipherParams Params
+}
+
+func NewMasta(secretKey []uint64, modulus uint64, cipherParams Params) Masta {
+	masta := Masta{
+		secretKey,
+		modulus,
+		cipherParams,
+	}
+
+	return masta
+}
+
+func (m *Masta) Encrypt(plaintext []uint64) []uint64 {
+	return m.EncryptWithNonce(plaintext, DefaultNonce)
+}
+
+func (m *Masta) Decrypt(ciphertext []uint64) []uint64 {
+	return m.DecryptWithNonce(ciphertext, DefaultNonce)
+}
+
+func (m *Masta) EncryptWithNonce(plaintext []uint64, nonce uint64) []uint64 {
+	mastaUtil := NewMastaUtil(m.SecretKey, m.Modulus, int(m.CipherParams.Rounds))
+	return encryptBlocks(plaintext, nonce, m.CipherParams.PlainSize, m.Modulus, mastaUtil.Keystream)
+}
+
+func (m *Masta) DecryptWithNonce(ciphertext []uint64, nonce uint64) []uint64 {
+	mastaUtil := NewMastaUtil(m.SecretKey, m.Modulus, int(m.CipherParams.Rounds))
+	return decryptBlocks(ciphertext, nonce, m.CipherParams.CipherSize, m.Modulus, mastaUtil.Keystream)
+}
+
+func (m *Masta) Keystream(nonce uint64, blockCounter uint64) []uint64 {
+	mastaUtil := NewMastaUtil(m.SecretKey, m.Modulus, int(m.CipherParams.Rounds))
+	return mastaUtil.Keystream(nonce, blockCounter)
+}
+
+func (m *Masta) Params() Params {
+	return m.CipherParams
+}
+
+func (m *Masta) PlainModulus() uint64 {
+	return m.Modulus
+}
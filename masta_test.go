@@ -0,0 +1,60 @@
+package pasta
+
+import (
+	"math/rand"
+	"testing"
+)
+
+const MastaTestModulus = 65537
+
+func TestMastaEncryptionDecryption(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, params := range []Params{Masta4, Masta5} {
+		secretKey := randomVector(rng, int(params.SecretKeySize), MastaTestModulus)
+		plaintext := randomVector(rng, 2*int(params.PlainSize)+3, MastaTestModulus)
+
+		masta := NewMasta(secretKey, MastaTestModulus, params)
+		ciphertext := masta.Encrypt(plaintext)
+		decrypted := masta.Decrypt(ciphertext)
+
+		if !equalSlices(decrypted, plaintext) {
+			t.Errorf("%v: different plaintexts. decrypted(%d), plaintext(%d)",
+				params, len(decrypted), len(plaintext))
+		}
+		if equalSlices(ciphertext, plaintext) {
+			t.Errorf("%v: ciphertext equals plaintext", params)
+		}
+	}
+}
+
+func TestMastaPolyMulMatchesMatrix(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	n := 8
+
+	masta := NewMastaUtil(make([]uint64, n), MastaTestModulus, 4)
+	x := randomVector(rng, n, MastaTestModulus)
+	alpha := randomVector(rng, n, MastaTestModulus)
+	eta := rng.Uint64() % MastaTestModulus
+
+	// column j of the matrix holds the coefficients of X^j * alpha(X)
+	column := make([]uint64, n)
+	copy(column, alpha)
+	expected := make([]uint64, n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			expected[i] = addMod(expected[i], mulMod(column[i], x[j], MastaTestModulus), MastaTestModulus)
+		}
+		// multiply by X: shift up, wrapping X^n to eta
+		last := column[n-1]
+		copy(column[1:], column[:n-1])
+		column[0] = mulMod(last, eta, MastaTestModulus)
+	}
+
+	copy(masta.state_, x)
+	masta.polyMul(alpha, eta)
+
+	if !equalSlices(masta.state_, expected) {
+		t.Errorf("different products. polyMul(%d), matrix(%d)", masta.state_, expected)
+	}
+}
@@ -0,0 +1,100 @@
+package pasta
+
+type MastaUtil struct {
+	sampler
+
+	secretKey_ SecretKey
+	state_     []uint64
+
+	rounds int
+}
+
+func NewMastaUtil(secretKey []uint64, modulus uint64, rounds int) MastaUtil {
+	return MastaUtil{
+		newSampler(modulus, nil),
+		secretKey,
+		make([]uint64, len(secretKey)),
+		rounds,
+	}
+}
+
+// KS = A[r+1] o S o A[r] o ... o S o A[1](K) + K, where every affine layer
+// multiplies by a random polynomial in F_p[X]/(X^n - eta) and adds a random
+// constant, and S is the chi-like map.
+func (m *MastaUtil) Keystream(nonce uint64, blockCounter uint64) []uint64 {
+	m.initShake(nonce, blockCounter)
+
+	// init state
+	copy(m.state_, m.secretKey_)
+
+	for r := 0; r < m.rounds; r++ {
+		m.affineLayer()
+		m.sboxChi()
+	}
+
+	m.affineLayer()
+
+	// feed-forward
+	ks := make([]uint64, len(m.state_))
+	for i := range ks {
+		ks[i] = addMod(m.state_[i], m.secretKey_[i], m.modulus)
+	}
+
+	return ks
+}
+
+// A(x) = alpha(X) * x(X) mod (X^n - eta) + c
+func (m *MastaUtil) affineLayer() {
+	eta := m.generateRandomFieldElement(false)
+	alpha := m.getRandomVector(false)
+
+	m.polyMul(alpha, eta)
+
+	for i := range m.state_ {
+		rc := m.generateRandomFieldElement(true)
+		m.state_[i] = addMod(m.state_[i], rc, m.modulus)
+	}
+}
+
+// state = alpha(X) * state(X) mod (X^n - eta)
+func (m *MastaUtil) polyMul(alpha []uint64, eta uint64) {
+	n := len(m.state_)
+	newState := make([]uint64, n)
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			mult := mulMod(alpha[i], m.state_[j], m.modulus)
+			k := i + j
+			if k >= n {
+				// X^n = eta
+				k -= n
+				mult = mulMod(mult, eta, m.modulus)
+			}
+			newState[k] = addMod(newState[k], mult, m.modulus)
+		}
+	}
+
+	copy(m.state_, newState)
+}
+
+// y[i] = x[i] + (x[i+2] + 1) * x[i+1], indices taken mod n
+func (m *MastaUtil) sboxChi() {
+	n := len(m.state_)
+	newState := make([]uint64, n)
+
+	for i := 0; i < n; i++ {
+		tmp := addMod(m.state_[(i+2)%n], 1, m.modulus)
+		tmp = mulMod(tmp, m.state_[(i+1)%n], m.modulus)
+		newState[i] = addMod(m.state_[i], tmp, m.modulus)
+	}
+
+	copy(m.state_, newState)
+}
+
+func (m *MastaUtil) getRandomVector(allowZero bool) []uint64 {
+	rc := make([]uint64, len(m.state_))
+	for i := range rc {
+		rc[i] = m.generateRandomFieldElement(allowZero)
+	}
+	return rc
+}
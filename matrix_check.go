@@ -0,0 +1,121 @@
+package pasta
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// GeneratedMatrix is a matrix Mij of the affine layers of one keystream
+// block. Round rounds is the final affine layer, Half is 1 or 2.
+type GeneratedMatrix struct {
+	BlockCounter uint64
+	Round, Half  int
+	Rows         [][]uint64
+}
+
+// GeneratedMatrices materializes the matrices of block blockCounter. They
+// depend on the nonce and block counter only, not on the key.
+func GeneratedMatrices(modulus uint64, params Params, nonce, blockCounter uint64) []GeneratedMatrix {
+	util := NewUtil(make([]uint64, params.SecretKeySize), modulus, int(params.Rounds))
+
+	var matrices []GeneratedMatrix
+	util.SetTracer(func(e TraceEvent) {
+		if e.Step != StepMatmul {
+			return
+		}
+
+		rows := make([][]uint64, util.t)
+		rows[0] = append([]uint64(nil), e.Rand...)
+		for i := 1; i < util.t; i++ {
+			rows[i] = util.calculateRow(rows[i-1], rows[0])
+		}
+		matrices = append(matrices, GeneratedMatrix{blockCounter, e.Round, e.Half, rows})
+	})
+	util.Keystream(nonce, blockCounter)
+
+	return matrices
+}
+
+// SingularMatrix reports a generated matrix of rank below t.
+type SingularMatrix struct {
+	BlockCounter uint64
+	Round, Half  int
+	Rank         int
+}
+
+// FindSingularMatrices checks that every matrix of blocks firstBlock to
+// firstBlock+blocks-1 is invertible mod the prime modulus, returning the
+// singular ones and the number of matrices checked. A modulus that is not
+// prime is an error, since ranks are only defined over a field.
+func FindSingularMatrices(modulus uint64, params Params, nonce, firstBlock, blocks uint64) ([]SingularMatrix, int, error) {
+	if !new(big.Int).SetUint64(modulus).ProbablyPrime(20) {
+		return nil, 0, fmt.Errorf("pasta: modulus %d is not prime", modulus)
+	}
+
+	var singular []SingularMatrix
+	checked := 0
+
+	for block := firstBlock; block-firstBlock < blocks; block++ {
+		for _, m := range GeneratedMatrices(modulus, params, nonce, block) {
+			checked++
+			rank, err := matrixRank(m.Rows, modulus)
+			if err != nil {
+				return nil, checked, err
+			}
+			if rank < len(m.Rows) {
+				singular = append(singular, SingularMatrix{block, m.Round, m.Half, rank})
+			}
+		}
+	}
+
+	return singular, checked, nil
+}
+
+// matrixRank is the rank of rows mod the prime modulus, by Gaussian
+// elimination on a copy. A pivot without an inverse means modulus is not
+// prime and is an error rather than a wrong rank.
+func matrixRank(rows [][]uint64, modulus uint64) (int, error) {
+	m := make([][]uint64, len(rows))
+	for i := range rows {
+		m[i] = append([]uint64(nil), rows[i]...)
+	}
+
+	p := new(big.Int).SetUint64(modulus)
+	rank := 0
+
+	for col := 0; col < len(m) && rank < len(m); col++ {
+		pivot := -1
+		for i := rank; i < len(m); i++ {
+			if m[i][col] != 0 {
+				pivot = i
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		m[rank], m[pivot] = m[pivot], m[rank]
+
+		inv := new(big.Int).ModInverse(new(big.Int).SetUint64(m[rank][col]), p)
+		if inv == nil {
+			return 0, fmt.Errorf("pasta: %d has no inverse mod %d, the modulus is not prime", m[rank][col], modulus)
+		}
+		scale := inv.Uint64()
+		for j := col; j < len(m[rank]); j++ {
+			m[rank][j] = mulMod(m[rank][j], scale, modulus)
+		}
+
+		for i := rank + 1; i < len(m); i++ {
+			factor := m[i][col]
+			if factor == 0 {
+				continue
+			}
+			for j := col; j < len(m[i]); j++ {
+				m[i][j] = subMod(m[i][j], mulMod(factor, m[rank][j], modulus), modulus)
+			}
+		}
+		rank++
+	}
+
+	return rank, nil
+}
@@ -0,0 +1,68 @@
+package pasta
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMatrixRank(t *testing.T) {
+	for _, c := range []struct {
+		rows [][]uint64
+		want int
+	}{
+		{[][]uint64{{1, 0}, {0, 1}}, 2},
+		{[][]uint64{{1, 2}, {2, 4}}, 1},
+		{[][]uint64{{0, 0}, {0, 0}}, 0},
+		{[][]uint64{{0, 1, 2}, {1, 0, 3}, {1, 1, 5}}, 2},
+		{[][]uint64{{3, 1, 4}, {1, 5, 9}, {2, 6, 5}}, 3},
+	} {
+		if got, err := matrixRank(c.rows, 7); err != nil || got != c.want {
+			t.Errorf("rank of %v = %d, %v, want %d", c.rows, got, err, c.want)
+		}
+	}
+
+	// 2 has no inverse mod 4: the rank would be wrong, not just unknown
+	if _, err := matrixRank([][]uint64{{2, 1}, {0, 1}}, 4); err == nil {
+		t.Errorf("expected an error for a composite modulus")
+	}
+}
+
+func TestGeneratedMatrices(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	modulus := uint64(65537)
+
+	matrices := GeneratedMatrices(modulus, Pasta4, DefaultNonce, 3)
+	if want := 2 * (int(Pasta4.Rounds) + 1); len(matrices) != want {
+		t.Fatalf("%d matrices, want %d", len(matrices), want)
+	}
+
+	// the materialized matrix is the one matmulRecurrence applies
+	util := NewUtil(make([]uint64, Pasta4.SecretKeySize), modulus, int(Pasta4.Rounds))
+	m := matrices[len(matrices)-1]
+	y := randomVector(rng, len(m.Rows), modulus)
+
+	want := append([]uint64(nil), y...)
+	util.matmulRecurrence(want, m.Rows[0])
+
+	for i, row := range m.Rows {
+		sum := uint64(0)
+		for j := range row {
+			sum = addMod(sum, mulMod(row[j], y[j], modulus), modulus)
+		}
+		if sum != want[i] {
+			t.Fatalf("row %d of M y = %d, want %d", i, sum, want[i])
+		}
+	}
+
+	for _, p := range []uint64{7, modulus} {
+		singular, checked, err := FindSingularMatrices(p, Pasta4, DefaultNonce, 0, 4)
+		if err != nil || checked != 4*len(matrices) || len(singular) != 0 {
+			t.Errorf("p=%d: checked %d matrices, %d singular: %v, %v", p, checked, len(singular), singular, err)
+		}
+	}
+	for _, p := range []uint64{0, 1, 65535} {
+		if _, _, err := FindSingularMatrices(p, Pasta4, DefaultNonce, 0, 1); err == nil {
+			t.Errorf("p=%d: expected an error", p)
+		}
+	}
+}
@@ -1,7 +1,8 @@
 package pasta
 
 import (
-	"math"
+	"context"
+	"sync"
 )
 
 const SecretKeySize = 256
@@ -9,67 +10,237 @@ const PlaintextSize = 128
 const CiphertextSize = 128
 
 type Params struct {
-	SecretKeySize uint64
-	PlainSize     uint64
-	CipherSize    uint64
-	Rounds        uint
+	SecretKeySize uint64 `json:"secret_key_size"`
+	PlainSize     uint64 `json:"plain_size"`
+	CipherSize    uint64 `json:"cipher_size"`
+	Rounds        uint   `json:"rounds"`
 }
 
-type Pasta struct {
-	SecretKey    SecretKey
+var (
+	Pasta3 = Params{SecretKeySize, PlaintextSize, CiphertextSize, 3}
+	Pasta4 = Params{64, 32, 32, 4}
+)
+
+// PastaOf is PASTA on field elements stored as T: a 17-bit instance can keep
+// keys, state and ciphertexts in uint32.
+type PastaOf[T Word] struct {
+	SecretKey    []T
 	Modulus      uint64
 	CipherParams Params
+
+	// XOF generating the round material, SHAKE128 when nil
+	XOF XOFFactory
+
+	// Utils reused by EncryptTo and DecryptTo, see getUtil
+	utils_ *utilCache[T]
 }
 
+type Pasta = PastaOf[uint64]
+
 func NewPasta(secretKey []uint64, modulus uint64, cipherParams Params) Pasta {
-	pasta := Pasta{
+	return NewPastaOf(secretKey, modulus, cipherParams)
+}
+
+func NewPastaOf[T Word](secretKey []T, modulus uint64, cipherParams Params) PastaOf[T] {
+	pasta := PastaOf[T]{
 		secretKey,
 		modulus,
 		cipherParams,
+		nil,
+		&utilCache[T]{},
 	}
 
 	return pasta
 }
 
-func (p *Pasta) Encrypt(plaintext []uint64) []uint64 {
-	nonce := uint64(123456789)
-	size := len(plaintext)
+func (p *PastaOf[T]) Encrypt(plaintext []T) []T {
+	return p.EncryptWithNonce(plaintext, DefaultNonce)
+}
+
+func (p *PastaOf[T]) Decrypt(ciphertext []T) []T {
+	return p.DecryptWithNonce(ciphertext, DefaultNonce)
+}
+
+func (p *PastaOf[T]) EncryptWithNonce(plaintext []T, nonce uint64) []T {
+	ciphertext := make([]T, len(plaintext))
+	p.EncryptToWithNonce(ciphertext, plaintext, nonce)
+
+	return ciphertext
+}
+
+func (p *PastaOf[T]) DecryptWithNonce(ciphertext []T, nonce uint64) []T {
+	plaintext := make([]T, len(ciphertext))
+	p.DecryptToWithNonce(plaintext, ciphertext, nonce)
+
+	return plaintext
+}
+
+// EncryptTo encrypts src into dst without allocating once warmed up. dst
+// may be src itself, and it panics if dst is shorter than src.
+func (p *PastaOf[T]) EncryptTo(dst, src []T) {
+	p.EncryptToWithNonce(dst, src, DefaultNonce)
+}
+
+// DecryptTo decrypts src into dst, see EncryptTo.
+func (p *PastaOf[T]) DecryptTo(dst, src []T) {
+	p.DecryptToWithNonce(dst, src, DefaultNonce)
+}
+
+func (p *PastaOf[T]) EncryptToWithNonce(dst, src []T, nonce uint64) {
+	p.xcryptTo(nil, dst, src, nonce, false)
+}
+
+func (p *PastaOf[T]) DecryptToWithNonce(dst, src []T, nonce uint64) {
+	p.xcryptTo(nil, dst, src, nonce, true)
+}
+
+// EncryptContext is EncryptToWithNonce checking ctx between keystream
+// blocks. When ctx is done it stops and returns ctx.Err() along with n, the
+// number of leading elements of dst already encrypted.
+func (p *PastaOf[T]) EncryptContext(ctx context.Context, dst, src []T, nonce uint64) (n int, err error) {
+	n = p.xcryptTo(ctx.Done(), dst, src, nonce, false)
+	if n < len(src) {
+		return n, ctx.Err()
+	}
+	return n, nil
+}
+
+// DecryptContext is DecryptToWithNonce checking ctx between keystream
+// blocks, see EncryptContext.
+func (p *PastaOf[T]) DecryptContext(ctx context.Context, dst, src []T, nonce uint64) (n int, err error) {
+	n = p.xcryptTo(ctx.Done(), dst, src, nonce, true)
+	if n < len(src) {
+		return n, ctx.Err()
+	}
+	return n, nil
+}
+
+// xcryptTo adds (or subtracts, to decrypt) the keystream of src into dst
+// block by block and returns the number of elements written, which is less
+// than len(src) only if done was closed.
+func (p *PastaOf[T]) xcryptTo(done <-chan struct{}, dst, src []T, nonce uint64, decrypt bool) int {
+	if len(dst) < len(src) {
+		panic("pasta: output smaller than input")
+	}
+
+	pastaUtil := p.getUtil()
+	defer p.putUtil(pastaUtil)
 
-	numBlock := int(math.Ceil(float64(size) / float64(p.CipherParams.PlainSize)))
+	blockSize := int(p.CipherParams.PlainSize)
+	if decrypt {
+		blockSize = int(p.CipherParams.CipherSize)
+	}
+
+	ks := pastaUtil.ks_
+	for b, start := uint64(0), 0; start < len(src); b, start = b+1, start+blockSize {
+		select {
+		case <-done:
+			return start
+		default:
+		}
 
-	pastaUtil := NewUtil(p.SecretKey, p.Modulus, int(p.CipherParams.Rounds))
-	ciphertext := make([]uint64, size)
-	copy(ciphertext, plaintext)
+		pastaUtil.KeystreamTo(ks, nonce, b)
 
-	for b := uint64(0); b < uint64(numBlock); b++ {
-		ks := pastaUtil.Keystream(nonce, b)
-		for i := int(b * p.CipherParams.PlainSize); i < int((b+1)*p.CipherParams.PlainSize) && i < size; i++ {
-			ciphertext[i] = (ciphertext[i] + ks[i-int(b*p.CipherParams.PlainSize)]) % p.Modulus
+		end := start + blockSize
+		if end > len(src) {
+			end = len(src)
+		}
+		field := pastaUtil.field_
+		for i := start; i < end; i++ {
+			c := field.Reduce(uint64(src[i]))
+			if !decrypt {
+				dst[i] = field.Add(c, ks[i-start])
+				continue
+			}
+			dst[i] = field.Sub(c, ks[i-start])
 		}
 	}
 
-	return ciphertext
+	return len(src)
+}
+
+// utilCache keeps the Utils of finished EncryptTo and DecryptTo calls for
+// the next ones. Unlike a sync.Pool it is never emptied by the garbage
+// collector, so once it holds a Util per concurrent caller the calls stop
+// allocating for good.
+type utilCache[T Word] struct {
+	mu    sync.Mutex
+	utils []*UtilOf[T]
 }
 
-func (p *Pasta) Decrypt(ciphertext []uint64) []uint64 {
-	nonce := uint64(123456789)
-	size := len(ciphertext)
+func (c *utilCache[T]) get() *UtilOf[T] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	numBlock := int(math.Ceil(float64(size) / float64(p.CipherParams.CipherSize)))
+	n := len(c.utils)
+	if n == 0 {
+		return nil
+	}
+	u := c.utils[n-1]
+	c.utils[n-1] = nil
+	c.utils = c.utils[:n-1]
+	return u
+}
+
+func (c *utilCache[T]) put(u *UtilOf[T]) {
+	c.mu.Lock()
+	c.utils = append(c.utils, u)
+	c.mu.Unlock()
+}
 
-	pasta := NewUtil(p.SecretKey, p.Modulus, int(p.CipherParams.Rounds))
-	plaintext := make([]uint64, size)
-	copy(plaintext, ciphertext)
+// drain empties the cache, returning the Utils it held.
+func (c *utilCache[T]) drain() []*UtilOf[T] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	for b := uint64(0); b < uint64(numBlock); b++ {
-		ks := pasta.Keystream(nonce, b)
-		for i := int(b * p.CipherParams.CipherSize); i < int((b+1)*p.CipherParams.CipherSize) && i < size; i++ {
-			if ks[i-int(b*p.CipherParams.PlainSize)] > plaintext[i] {
-				plaintext[i] += p.Modulus
+	utils := c.utils
+	c.utils = nil
+	return utils
+}
+
+// getUtil returns a cached Util when one was built for the current key,
+// modulus and rounds. Caching is skipped for custom XOFs, which cannot be
+// compared, and for Pastas not built by NewPastaOf.
+func (p *PastaOf[T]) getUtil() *UtilOf[T] {
+	if p.utils_ != nil && p.XOF == nil {
+		if u := p.utils_.get(); u != nil {
+			if p.matches(u) {
+				return u
 			}
-			plaintext[i] = plaintext[i] - ks[i-int(b*p.CipherParams.PlainSize)]
+			u.wipeState()
 		}
 	}
 
-	return plaintext
+	u := NewUtilOf(p.SecretKey, p.Modulus, int(p.CipherParams.Rounds), p.XOF)
+	return &u
+}
+
+// putUtil caches u, or wipes its state if it cannot be reused. The key
+// is left alone: it is p's.
+func (p *PastaOf[T]) putUtil(u *UtilOf[T]) {
+	if p.utils_ == nil || p.XOF != nil || !p.matches(u) {
+		u.wipeState()
+		return
+	}
+	p.utils_.put(u)
+}
+
+func (p *PastaOf[T]) matches(u *UtilOf[T]) bool {
+	if len(u.secretKey_) != len(p.SecretKey) || u.modulus != p.Modulus || u.rounds != int(p.CipherParams.Rounds) {
+		return false
+	}
+	return len(p.SecretKey) == 0 || &u.secretKey_[0] == &p.SecretKey[0]
+}
+
+func (p *PastaOf[T]) Keystream(nonce uint64, blockCounter uint64) []T {
+	pastaUtil := NewUtilOf(p.SecretKey, p.Modulus, int(p.CipherParams.Rounds), p.XOF)
+	return pastaUtil.Keystream(nonce, blockCounter)
+}
+
+func (p *PastaOf[T]) Params() Params {
+	return p.CipherParams
+}
+
+func (p *PastaOf[T]) PlainModulus() uint64 {
+	return p.Modulus
 }
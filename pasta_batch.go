@@ -0,0 +1,90 @@
+package pasta
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// EncryptBatch encrypts messages[i] under nonces[i], returning the
+// ciphertexts in order. All ciphertexts share one allocation and the
+// keystream workspaces are reused across messages. A nonce that appears
+// twice in nonces is an error, since it would reuse the keystream.
+func (p *PastaOf[T]) EncryptBatch(messages [][]T, nonces []uint64) ([][]T, error) {
+	return p.EncryptBatchParallel(messages, nonces, 1)
+}
+
+// DecryptBatch decrypts ciphertexts[i] under nonces[i], see EncryptBatch.
+// Nonces may repeat, since decryption reuses no keystream.
+func (p *PastaOf[T]) DecryptBatch(ciphertexts [][]T, nonces []uint64) ([][]T, error) {
+	return p.DecryptBatchParallel(ciphertexts, nonces, 1)
+}
+
+// EncryptBatchParallel is EncryptBatch spread over workers goroutines, or
+// GOMAXPROCS goroutines when workers <= 0.
+func (p *PastaOf[T]) EncryptBatchParallel(messages [][]T, nonces []uint64, workers int) ([][]T, error) {
+	return p.xcryptBatch(messages, nonces, workers, false)
+}
+
+// DecryptBatchParallel is DecryptBatch spread over workers goroutines, see
+// EncryptBatchParallel.
+func (p *PastaOf[T]) DecryptBatchParallel(ciphertexts [][]T, nonces []uint64, workers int) ([][]T, error) {
+	return p.xcryptBatch(ciphertexts, nonces, workers, true)
+}
+
+func (p *PastaOf[T]) xcryptBatch(src [][]T, nonces []uint64, workers int, decrypt bool) ([][]T, error) {
+	if len(nonces) != len(src) {
+		return nil, fmt.Errorf("pasta: %d nonces for %d messages", len(nonces), len(src))
+	}
+	if !decrypt {
+		seen := make(map[uint64]int, len(nonces))
+		for i, n := range nonces {
+			if j, ok := seen[n]; ok {
+				return nil, fmt.Errorf("pasta: nonce %d used by messages %d and %d", n, j, i)
+			}
+			seen[n] = i
+		}
+	}
+
+	total := 0
+	for _, m := range src {
+		total += len(m)
+	}
+
+	buf := make([]T, total)
+	dst := make([][]T, len(src))
+	for i, m := range src {
+		dst[i], buf = buf[:len(m):len(m)], buf[len(m):]
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(src) {
+		workers = len(src)
+	}
+
+	if workers <= 1 {
+		for i := range src {
+			p.xcryptTo(nil, dst[i], src[i], nonces[i], decrypt)
+		}
+		return dst, nil
+	}
+
+	// every worker takes the next message, with its own cached Util
+	var next int64 = -1
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := int(atomic.AddInt64(&next, 1)); i < len(src); i = int(atomic.AddInt64(&next, 1)) {
+				p.xcryptTo(nil, dst[i], src[i], nonces[i], decrypt)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return dst, nil
+}
@@ -0,0 +1,97 @@
+package pasta
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestEncryptBatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	secretKey := randomVector(rng, int(Pasta4.SecretKeySize), 65537)
+	pasta := NewPasta(secretKey, 65537, Pasta4)
+
+	messages := make([][]uint64, 50)
+	nonces := make([]uint64, len(messages))
+	for i := range messages {
+		messages[i] = randomVector(rng, rng.Intn(70), 65537)
+		nonces[i] = rng.Uint64()
+	}
+
+	for _, workers := range []int{1, 4, 0} {
+		ciphertexts, err := pasta.EncryptBatchParallel(messages, nonces, workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range messages {
+			if !equalSlices(ciphertexts[i], pasta.EncryptWithNonce(messages[i], nonces[i])) {
+				t.Errorf("workers %d: ciphertext %d differs from EncryptWithNonce", workers, i)
+			}
+		}
+
+		decrypted, err := pasta.DecryptBatchParallel(ciphertexts, nonces, workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range messages {
+			if !equalSlices(decrypted[i], messages[i]) {
+				t.Errorf("workers %d: message %d does not round trip", workers, i)
+			}
+		}
+	}
+}
+
+func TestEncryptBatchErrors(t *testing.T) {
+	pasta := NewPasta(make([]uint64, Pasta4.SecretKeySize), 65537, Pasta4)
+
+	if _, err := pasta.EncryptBatch([][]uint64{{1}, {2}}, []uint64{1}); err == nil {
+		t.Errorf("expected error for missing nonce")
+	}
+	if _, err := pasta.EncryptBatch([][]uint64{{1}, {2}, {3}}, []uint64{5, 6, 5}); err == nil {
+		t.Errorf("expected error for duplicate nonce")
+	}
+
+	// decrypting the same ciphertext twice reuses no keystream
+	ciphertext := pasta.EncryptWithNonce([]uint64{1}, 5)
+	out, err := pasta.DecryptBatchParallel([][]uint64{ciphertext, ciphertext}, []uint64{5, 5}, 2)
+	if err != nil || out[0][0] != 1 || out[1][0] != 1 {
+		t.Errorf("DecryptBatchParallel with a repeated nonce: %v, %v", out, err)
+	}
+	if out, err := pasta.EncryptBatch(nil, nil); err != nil || len(out) != 0 {
+		t.Errorf("empty batch: %v, %v", out, err)
+	}
+}
+
+func BenchmarkEncryptBatch(b *testing.B) {
+	rng := rand.New(rand.NewSource(2))
+	secretKey := randomVector(rng, int(Pasta4.SecretKeySize), 65537)
+	pasta := NewPasta(secretKey, 65537, Pasta4)
+
+	messages := make([][]uint64, 256)
+	nonces := make([]uint64, len(messages))
+	for i := range messages {
+		messages[i] = randomVector(rng, 4, 65537)
+		nonces[i] = uint64(i)
+	}
+
+	b.Run("Encrypt", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := range messages {
+				fresh := NewPasta(secretKey, 65537, Pasta4)
+				fresh.EncryptWithNonce(messages[j], nonces[j])
+			}
+		}
+	})
+	b.Run("EncryptBatch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			pasta.EncryptBatch(messages, nonces)
+		}
+	})
+	b.Run("EncryptBatchParallel", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			pasta.EncryptBatchParallel(messages, nonces, 0)
+		}
+	})
+}
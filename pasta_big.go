@@ -0,0 +1,318 @@
+package pasta
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// BigPasta is PASTA over moduli of any size, with keys, ciphertexts and
+// keystream as arbitrary-precision field elements. Pasta remains the fast
+// path for moduli below 2^64, including those of 2^63 or more. For those
+// moduli both produce the same keystream and ciphertexts.
+type BigPasta struct {
+	SecretKey    []*big.Int
+	Modulus      *big.Int
+	CipherParams Params
+
+	// XOF generating the round material, SHAKE128 when nil
+	XOF XOFFactory
+}
+
+// NewBigPasta returns an error unless modulus is an odd prime, the params
+// are valid PASTA params and the key has SecretKeySize elements.
+func NewBigPasta(secretKey []*big.Int, modulus *big.Int, cipherParams Params) (BigPasta, error) {
+	if err := validateBigModulus(modulus); err != nil {
+		return BigPasta{}, err
+	}
+	if err := validatePastaParams(cipherParams); err != nil {
+		return BigPasta{}, fmt.Errorf("pasta: %w", err)
+	}
+	if uint64(len(secretKey)) != cipherParams.SecretKeySize {
+		return BigPasta{}, fmt.Errorf("pasta: secret key has %d elements, expected %d",
+			len(secretKey), cipherParams.SecretKeySize)
+	}
+
+	pasta := BigPasta{
+		secretKey,
+		modulus,
+		cipherParams,
+		nil,
+	}
+
+	return pasta, nil
+}
+
+// validateBigModulus requires a prime above 2: elements are then at least
+// 2 bits wide and rejection sampling of non-zero elements terminates.
+func validateBigModulus(modulus *big.Int) error {
+	if modulus == nil || modulus.Cmp(big.NewInt(2)) <= 0 || !modulus.ProbablyPrime(20) {
+		return fmt.Errorf("pasta: modulus %v is not an odd prime", modulus)
+	}
+	return nil
+}
+
+func (p *BigPasta) Encrypt(plaintext []*big.Int) []*big.Int {
+	return p.EncryptWithNonce(plaintext, DefaultNonce)
+}
+
+func (p *BigPasta) Decrypt(ciphertext []*big.Int) []*big.Int {
+	return p.DecryptWithNonce(ciphertext, DefaultNonce)
+}
+
+func (p *BigPasta) EncryptWithNonce(plaintext []*big.Int, nonce uint64) []*big.Int {
+	pastaUtil := p.util()
+	blockSize := p.CipherParams.PlainSize
+	size := uint64(len(plaintext))
+
+	ciphertext := make([]*big.Int, size)
+	for b := uint64(0); b*blockSize < size; b++ {
+		ks := pastaUtil.Keystream(nonce, b)
+		for i := b * blockSize; i < (b+1)*blockSize && i < size; i++ {
+			ciphertext[i] = new(big.Int).Add(plaintext[i], ks[i-b*blockSize])
+			ciphertext[i].Mod(ciphertext[i], p.Modulus)
+		}
+	}
+
+	return ciphertext
+}
+
+func (p *BigPasta) DecryptWithNonce(ciphertext []*big.Int, nonce uint64) []*big.Int {
+	pastaUtil := p.util()
+	blockSize := p.CipherParams.CipherSize
+	size := uint64(len(ciphertext))
+
+	plaintext := make([]*big.Int, size)
+	for b := uint64(0); b*blockSize < size; b++ {
+		ks := pastaUtil.Keystream(nonce, b)
+		for i := b * blockSize; i < (b+1)*blockSize && i < size; i++ {
+			plaintext[i] = new(big.Int).Sub(ciphertext[i], ks[i-b*blockSize])
+			plaintext[i].Mod(plaintext[i], p.Modulus)
+		}
+	}
+
+	return plaintext
+}
+
+func (p *BigPasta) Keystream(nonce uint64, blockCounter uint64) []*big.Int {
+	pastaUtil := p.util()
+	return pastaUtil.Keystream(nonce, blockCounter)
+}
+
+// util panics on a BigPasta with invalid fields, which NewBigPasta does
+// not build.
+func (p *BigPasta) util() BigUtil {
+	if err := validatePastaParams(p.CipherParams); err != nil {
+		panic(err)
+	}
+	pastaUtil, err := NewBigUtil(p.SecretKey, p.Modulus, int(p.CipherParams.Rounds), p.XOF)
+	if err != nil {
+		panic(err)
+	}
+	return pastaUtil
+}
+
+// BigUtil is Util on math/big field elements.
+type BigUtil struct {
+	newXOF_ XOFFactory
+	xof_    XOF
+
+	secretKey_       []*big.Int
+	state1_, state2_ []*big.Int
+
+	// candidates are squeezed in elementBytes big-endian bytes and masked
+	// to the bit length of the modulus, as Util does with 8 bytes
+	modulus      *big.Int
+	elementBytes int
+
+	t, rounds int
+}
+
+// NewBigUtil returns an error unless modulus is an odd prime and the key
+// has two non-empty halves.
+func NewBigUtil(secretKey []*big.Int, modulus *big.Int, rounds int, newXOF XOFFactory) (BigUtil, error) {
+	if err := validateBigModulus(modulus); err != nil {
+		return BigUtil{}, err
+	}
+	if len(secretKey) < 2 || len(secretKey)%2 != 0 {
+		return BigUtil{}, fmt.Errorf("pasta: invalid secret key size %d", len(secretKey))
+	}
+	if rounds < 0 {
+		return BigUtil{}, fmt.Errorf("pasta: invalid number of rounds %d", rounds)
+	}
+
+	if newXOF == nil {
+		newXOF = NewShake128
+	}
+	t := len(secretKey) / 2
+
+	return BigUtil{
+		newXOF,
+		nil,
+		secretKey,
+		newBigVector(t),
+		newBigVector(t),
+		modulus,
+		8 * ((modulus.BitLen() + 63) / 64),
+		t,
+		rounds,
+	}, nil
+}
+
+func newBigVector(size int) []*big.Int {
+	v := make([]*big.Int, size)
+	for i := range v {
+		v[i] = new(big.Int)
+	}
+	return v
+}
+
+func (p *BigUtil) Keystream(nonce uint64, blockCounter uint64) []*big.Int {
+	p.initShake(nonce, blockCounter)
+
+	// init state
+	for i := 0; i < p.t; i++ {
+		p.state1_[i].Mod(p.secretKey_[i], p.modulus)
+		p.state2_[i].Mod(p.secretKey_[p.t+i], p.modulus)
+	}
+
+	for r := 0; r < p.rounds; r++ {
+		p.linearLayer()
+		if r == p.rounds-1 {
+			p.sboxCube(p.state1_)
+			p.sboxCube(p.state2_)
+		} else {
+			p.sboxFeistel(p.state1_)
+			p.sboxFeistel(p.state2_)
+		}
+	}
+
+	// final affine with mixing afterwards
+	p.linearLayer()
+
+	ks := make([]*big.Int, p.t)
+	for i := range ks {
+		ks[i] = new(big.Int).Set(p.state1_[i])
+	}
+
+	return ks
+}
+
+func (p *BigUtil) initShake(nonce, blockCounter uint64) {
+	p.xof_ = newSeededXOF(p.newXOF_, nonce, blockCounter)
+}
+
+func (p *BigUtil) generateRandomFieldElement(allowZero bool) *big.Int {
+	randomBytes := make([]byte, p.elementBytes)
+	mask := new(big.Int).Lsh(big.NewInt(1), uint(p.modulus.BitLen()))
+	mask.Sub(mask, big.NewInt(1))
+
+	for {
+		if _, err := p.xof_.Read(randomBytes); err != nil {
+			panic("XOF squeeze failed")
+		}
+
+		ele := new(big.Int).SetBytes(randomBytes)
+		ele.And(ele, mask)
+
+		if !allowZero && ele.Sign() == 0 {
+			continue
+		}
+
+		if ele.Cmp(p.modulus) < 0 {
+			return ele
+		}
+	}
+}
+
+// Aij(y) = Mij X y + cij
+func (p *BigUtil) linearLayer() {
+	p.matmul(p.state1_)
+	p.matmul(p.state2_)
+
+	p.addRc(p.state1_)
+	p.addRc(p.state2_)
+
+	p.mix()
+}
+
+// Mij X y, materializing every row with the row recurrence
+func (p *BigUtil) matmul(state []*big.Int) {
+	firstRow := make([]*big.Int, p.t)
+	for i := range firstRow {
+		firstRow[i] = p.generateRandomFieldElement(false)
+	}
+
+	newState := newBigVector(p.t)
+	currRow := firstRow
+	tmp := new(big.Int)
+
+	for i := 0; i < p.t; i++ {
+		for j := 0; j < p.t; j++ {
+			tmp.Mul(currRow[j], state[j])
+			newState[i].Add(newState[i], tmp)
+		}
+		newState[i].Mod(newState[i], p.modulus)
+
+		if i != p.t-1 {
+			currRow = p.calculateRow(currRow, firstRow)
+		}
+	}
+
+	for i := range state {
+		state[i].Set(newState[i])
+	}
+}
+
+func (p *BigUtil) calculateRow(prevRow, firstRow []*big.Int) []*big.Int {
+	out := newBigVector(p.t)
+	prevRowLast := prevRow[p.t-1]
+
+	for j := 0; j < p.t; j++ {
+		out[j].Mul(firstRow[j], prevRowLast)
+		if j > 0 {
+			out[j].Add(out[j], prevRow[j-1])
+		}
+		out[j].Mod(out[j], p.modulus)
+	}
+
+	return out
+}
+
+// + cij
+func (p *BigUtil) addRc(state []*big.Int) {
+	for i := 0; i < p.t; i++ {
+		state[i].Add(state[i], p.generateRandomFieldElement(true))
+		state[i].Mod(state[i], p.modulus)
+	}
+}
+
+// [S(x)]i = (x)3
+func (p *BigUtil) sboxCube(state []*big.Int) {
+	three := big.NewInt(3)
+	for i := 0; i < p.t; i++ {
+		state[i].Exp(state[i], three, p.modulus)
+	}
+}
+
+// S'(x) = x + (rot(-1)(x) . m)^2
+func (p *BigUtil) sboxFeistel(state []*big.Int) {
+	square := new(big.Int)
+	for i := p.t - 1; i > 0; i-- {
+		square.Mul(state[i-1], state[i-1])
+		state[i].Add(state[i], square)
+		state[i].Mod(state[i], p.modulus)
+	}
+}
+
+func (p *BigUtil) mix() {
+	sum := new(big.Int)
+	for i := 0; i < p.t; i++ {
+		sum.Add(p.state1_[i], p.state2_[i])
+
+		p.state1_[i].Add(p.state1_[i], sum)
+		p.state1_[i].Mod(p.state1_[i], p.modulus)
+
+		p.state2_[i].Add(p.state2_[i], sum)
+		p.state2_[i].Mod(p.state2_[i], p.modulus)
+	}
+}
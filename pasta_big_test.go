@@ -0,0 +1,110 @@
+package pasta
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestBigPastaMatchesPasta(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, modulus := range []uint64{65537, 8088322049, 1096486890805657601, 18446744073709551557} {
+		secretKey := randomVector(rng, int(Pasta4.SecretKeySize), modulus)
+		pasta := NewPasta(secretKey, modulus, Pasta4)
+		bigPasta, err := NewBigPasta(toBig(secretKey), new(big.Int).SetUint64(modulus), Pasta4)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := pasta.Keystream(DefaultNonce, 3)
+		ks := bigPasta.Keystream(DefaultNonce, 3)
+		for i := range expected {
+			if !ks[i].IsUint64() || ks[i].Uint64() != expected[i] {
+				t.Fatalf("modulus %d: keystream[%d] = %v, expected %d", modulus, i, ks[i], expected[i])
+			}
+		}
+
+		plaintext := randomVector(rng, int(Pasta4.PlainSize)+5, modulus)
+		expected = pasta.Encrypt(plaintext)
+		ciphertext := bigPasta.Encrypt(toBig(plaintext))
+		for i := range expected {
+			if !ciphertext[i].IsUint64() || ciphertext[i].Uint64() != expected[i] {
+				t.Fatalf("modulus %d: ciphertext[%d] = %v, expected %d", modulus, i, ciphertext[i], expected[i])
+			}
+		}
+	}
+}
+
+func TestBigPastaEncryptionDecryption(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+
+	// 2^127 - 1 and a 192-bit prime
+	mersenne127 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1))
+	prime192, _ := new(big.Int).SetString("6277101735386680763835789423207666416083908700390324961279", 10)
+
+	for _, modulus := range []*big.Int{mersenne127, prime192} {
+		secretKey := randomBigVector(rng, int(Pasta4.SecretKeySize), modulus)
+		plaintext := randomBigVector(rng, int(Pasta4.PlainSize)+7, modulus)
+
+		pasta, err := NewBigPasta(secretKey, modulus, Pasta4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext := pasta.Encrypt(plaintext)
+		decrypted := pasta.Decrypt(ciphertext)
+
+		for i := range plaintext {
+			if ciphertext[i].Sign() < 0 || ciphertext[i].Cmp(modulus) >= 0 {
+				t.Errorf("modulus %v: ciphertext[%d] = %v is not reduced", modulus, i, ciphertext[i])
+			}
+			if decrypted[i].Cmp(plaintext[i]) != 0 {
+				t.Errorf("modulus %v: decrypted[%d] = %v, expected %v", modulus, i, decrypted[i], plaintext[i])
+			}
+		}
+
+		ks := pasta.Keystream(DefaultNonce, 0)
+		if ks[0].BitLen() <= 64 && ks[1].BitLen() <= 64 && ks[2].BitLen() <= 64 {
+			t.Errorf("modulus %v: keystream does not use the full modulus width", modulus)
+		}
+	}
+}
+
+func TestNewBigPastaErrors(t *testing.T) {
+	key := toBig(make([]uint64, Pasta4.SecretKeySize))
+
+	for _, modulus := range []*big.Int{nil, big.NewInt(0), big.NewInt(1), big.NewInt(2), big.NewInt(65535)} {
+		if _, err := NewBigPasta(key, modulus, Pasta4); err == nil {
+			t.Errorf("modulus %v: expected an error", modulus)
+		}
+		if _, err := NewBigUtil(key, modulus, 4, nil); err == nil {
+			t.Errorf("modulus %v: expected an error from NewBigUtil", modulus)
+		}
+	}
+
+	modulus := big.NewInt(65537)
+	for _, params := range []Params{{64, 0, 0, 4}, {64, 33, 33, 4}, {63, 16, 16, 4}} {
+		if _, err := NewBigPasta(toBig(make([]uint64, params.SecretKeySize)), modulus, params); err == nil {
+			t.Errorf("params %v: expected an error", params)
+		}
+	}
+	if _, err := NewBigPasta(key[:10], modulus, Pasta4); err == nil {
+		t.Error("expected an error for a short key")
+	}
+}
+
+func toBig(v []uint64) []*big.Int {
+	out := make([]*big.Int, len(v))
+	for i, x := range v {
+		out[i] = new(big.Int).SetUint64(x)
+	}
+	return out
+}
+
+func randomBigVector(rng *rand.Rand, size int, modulus *big.Int) []*big.Int {
+	v := make([]*big.Int, size)
+	for i := range v {
+		v[i] = new(big.Int).Rand(rng, modulus)
+	}
+	return v
+}
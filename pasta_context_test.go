@@ -0,0 +1,70 @@
+package pasta
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// countingXOF is SHAKE128 without Reset, so a new one is built per block.
+type countingXOF struct {
+	XOF
+}
+
+func TestEncryptContextStopsBetweenBlocks(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	secretKey := randomVector(rng, int(Pasta4.SecretKeySize), 65537)
+	plaintext := randomVector(rng, 10*int(Pasta4.PlainSize), 65537)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	blocks := 0
+	pasta := NewPasta(secretKey, 65537, Pasta4)
+	pasta.XOF = func() XOF {
+		blocks++
+		if blocks == 2 {
+			cancel()
+		}
+		return countingXOF{NewShake128()}
+	}
+
+	dst := make([]uint64, len(plaintext))
+	n, err := pasta.EncryptContext(ctx, dst, plaintext, DefaultNonce)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if n != 2*int(Pasta4.PlainSize) {
+		t.Errorf("n = %d, expected %d", n, 2*Pasta4.PlainSize)
+	}
+
+	reference := NewPasta(secretKey, 65537, Pasta4)
+	expected := reference.Encrypt(plaintext)
+	if !equalSlices(dst[:n], expected[:n]) {
+		t.Errorf("encrypted prefix differs from Encrypt")
+	}
+}
+
+func TestDecryptContext(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	secretKey := randomVector(rng, int(Pasta4.SecretKeySize), 65537)
+	plaintext := randomVector(rng, 100, 65537)
+	pasta := NewPasta(secretKey, 65537, Pasta4)
+
+	ciphertext := pasta.EncryptWithNonce(plaintext, 9)
+	dst := make([]uint64, len(ciphertext))
+	n, err := pasta.DecryptContext(context.Background(), dst, ciphertext, 9)
+	if err != nil || n != len(plaintext) {
+		t.Fatalf("n = %d, err = %v", n, err)
+	}
+	if !equalSlices(dst, plaintext) {
+		t.Errorf("different plaintexts")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if n, err := pasta.DecryptContext(ctx, dst, ciphertext, 9); n != 0 || !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled context: n = %d, err = %v", n, err)
+	}
+}
@@ -0,0 +1,208 @@
+package pasta
+
+import "math/bits"
+
+// karatsubaThreshold is the operand length below which polyMul falls back to
+// schoolbook multiplication.
+const karatsubaThreshold = 32
+
+// matmulRecurrence computes Mij X y without materializing Mij.
+//
+// calculateRow derives row i+1 from row i as row[i+1][j] = r[j] * row[i][t-1]
+// + row[i][j-1], with r the first row. Reading row i as the polynomial
+// R_i(X) = sum row[i][j] X^j, that is R_{i+1} = X * R_i mod f with
+// f(X) = X^t - r(X), so R_i = X^(t+i) mod f. Hence (Mij X y)[i] = s[t+i],
+// where s[0..t-1] = y and s satisfies the linear recurrence with
+// characteristic polynomial f. Its generating function S(X) is P(X) / Q(X),
+// with Q(X) = X^t f(1/X) = 1 - sum r[j] X^(t-j) and P = S * Q mod X^t, so
+// the t outputs cost one power series inversion and two multiplications,
+// O(t^1.58) with Karatsuba.
+func (p *UtilOf[T]) matmulRecurrence(state []T, rand []T) {
+	t := p.t
+	p.resetScratch()
+
+	q := p.alloc(t + 1)
+	q[0] = p.field_.Reduce(1)
+	for j := 0; j < t; j++ {
+		q[t-j] = p.field_.Sub(0, rand[j])
+	}
+
+	y := p.alloc(t)
+	for j := range y {
+		y[j] = p.field_.Reduce(uint64(state[j]))
+	}
+
+	// P = y * Q mod X^t
+	pp := p.polyMul(y, q)[:t]
+
+	// S = P / Q mod X^2t, the outputs are its top t coefficients
+	s := p.polyMul(pp, p.polyInverse(q, 2*t))
+	copy(state, s[t:2*t])
+}
+
+// polyInverse returns a^-1 mod X^n by Newton iteration, for a[0] = 1.
+func (p *UtilOf[T]) polyInverse(a []T, n int) []T {
+	g := p.alloc(1)
+	g[0] = p.field_.Reduce(1)
+
+	for k := 1; k < n; k *= 2 {
+		k2 := 2 * k
+		if k2 > n {
+			k2 = n
+		}
+
+		// g = g * (2 - a * g) mod X^k2
+		ak := a
+		if len(ak) > k2 {
+			ak = ak[:k2]
+		}
+		e := p.polyMul(ak, g)
+		if len(e) > k2 {
+			e = e[:k2]
+		}
+		for i := range e {
+			e[i] = p.field_.Sub(0, e[i])
+		}
+		e[0] = p.field_.Add(e[0], p.field_.Reduce(2))
+
+		g = p.polyMul(g, e)
+		if len(g) > k2 {
+			g = g[:k2]
+		}
+	}
+
+	return g
+}
+
+// polyMul returns a * b, with len(a) + len(b) - 1 coefficients.
+func (p *UtilOf[T]) polyMul(a, b []T) []T {
+	out := p.alloc(len(a) + len(b) - 1)
+	p.karatsuba(out, a, b)
+	return out
+}
+
+// karatsuba adds a * b into out.
+func (p *UtilOf[T]) karatsuba(out, a, b []T) {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	if len(b) == 0 {
+		return
+	}
+
+	if len(b) < karatsubaThreshold {
+		if useAVX2 && p.modulus < vectorMaxModulus {
+			p.schoolbookVector(out, a, b)
+		} else {
+			p.schoolbook(out, a, b)
+		}
+		return
+	}
+
+	// split at m: a = a0 + X^m a1, b = b0 + X^m b1
+	m := (len(a) + 1) / 2
+	if m >= len(b) {
+		// unbalanced operands, multiply b by both halves of a
+		p.karatsuba(out, a[:m], b)
+		p.karatsuba(out[m:], a[m:], b)
+		return
+	}
+	a0, a1 := a[:m], a[m:]
+	b0, b1 := b[:m], b[m:]
+
+	z0 := p.polyMul(a0, b0)
+	z2 := p.polyMul(a1, b1)
+
+	// z1 = (a0 + a1)(b0 + b1) - z0 - z2
+	z1 := p.polyMul(p.polyAdd(a0, a1), p.polyAdd(b0, b1))
+	for i := range z0 {
+		z1[i] = p.field_.Sub(z1[i], z0[i])
+	}
+	for i := range z2 {
+		z1[i] = p.field_.Sub(z1[i], z2[i])
+	}
+
+	for i := range z0 {
+		out[i] = p.field_.Add(out[i], z0[i])
+	}
+	for i := range z1 {
+		out[m+i] = p.field_.Add(out[m+i], z1[i])
+	}
+	for i := range z2 {
+		out[2*m+i] = p.field_.Add(out[2*m+i], z2[i])
+	}
+}
+
+// schoolbook adds a * b into out, accumulating every output coefficient in
+// 192 bits and reducing it once.
+func (p *UtilOf[T]) schoolbook(out, a, b []T) {
+	for k := range out[:len(a)+len(b)-1] {
+		var acc2, acc1, acc0 uint64
+
+		lo := k - len(b) + 1
+		if lo < 0 {
+			lo = 0
+		}
+		for i := lo; i < len(a) && i <= k; i++ {
+			hi, low := bits.Mul64(uint64(a[i]), uint64(b[k-i]))
+			var carry uint64
+			acc0, carry = bits.Add64(acc0, low, 0)
+			acc1, carry = bits.Add64(acc1, hi, carry)
+			acc2 += carry
+		}
+
+		r := bits.Rem64(0, acc2, p.modulus)
+		r = bits.Rem64(r, acc1, p.modulus)
+		r = bits.Rem64(r, acc0, p.modulus)
+		out[k] = p.field_.Add(out[k], T(r))
+	}
+}
+
+func (p *UtilOf[T]) polyAdd(a, b []T) []T {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	out := p.alloc(len(a))
+	copy(out, a)
+	for i := range b {
+		out[i] = p.field_.Add(out[i], b[i])
+	}
+	return out
+}
+
+// resetScratch releases every slice handed out by alloc.
+func (p *UtilOf[T]) resetScratch() {
+	p.scratchPos_ = 0
+	p.limbsPos_ = 0
+}
+
+// alloc returns n zeroed elements of the scratch arena. When the arena is
+// exhausted a larger one replaces it, leaving earlier slices valid, so the
+// arena stops growing after the first few keystream blocks.
+func (p *UtilOf[T]) alloc(n int) []T {
+	if p.scratchPos_+n > len(p.scratch_) {
+		p.scratch_ = make([]T, 2*len(p.scratch_)+n)
+		p.scratchPos_ = 0
+	}
+
+	out := p.scratch_[p.scratchPos_ : p.scratchPos_+n : p.scratchPos_+n]
+	p.scratchPos_ += n
+	for i := range out {
+		out[i] = 0
+	}
+
+	return out
+}
+
+// allocLimbs is alloc for limb vectors, which are fully overwritten.
+func (p *UtilOf[T]) allocLimbs(n int) []uint32 {
+	if p.limbsPos_+n > len(p.limbs_) {
+		p.limbs_ = make([]uint32, 2*len(p.limbs_)+n)
+		p.limbsPos_ = 0
+	}
+
+	out := p.limbs_[p.limbsPos_ : p.limbsPos_+n : p.limbsPos_+n]
+	p.limbsPos_ += n
+
+	return out
+}
@@ -0,0 +1,54 @@
+package pasta
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestMatmulRecurrenceMatchesDense(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, modulus := range []uint64{7, 65537, 8088322049, 1096486890805657601} {
+		for _, size := range []int{1, 2, 5, 32, 33, 100, 128} {
+			util := NewUtil(make([]uint64, 2*size), modulus, 3)
+			for trial := 0; trial < 3; trial++ {
+				rand := randomVector(rng, size, modulus)
+				state := randomVector(rng, size, modulus)
+
+				dense := make(Block, size)
+				copy(dense, state)
+				util.matmulDense(dense, rand)
+
+				recurrence := make(Block, size)
+				copy(recurrence, state)
+				util.matmulRecurrence(recurrence, rand)
+
+				if !equalSlices(dense, recurrence) {
+					t.Fatalf("modulus %d, t %d: dense(%d), recurrence(%d)", modulus, size, dense, recurrence)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkMatmul(b *testing.B) {
+	rng := rand.New(rand.NewSource(2))
+
+	for _, modulus := range []uint64{65537, 1096486890805657601} {
+		util := NewUtil(make([]uint64, SecretKeySize), modulus, 3)
+		rand := randomVector(rng, PastaT, modulus)
+		state := randomVector(rng, PastaT, modulus)
+
+		b.Run(fmt.Sprintf("dense/%d", modulus), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				util.matmulDense(state, rand)
+			}
+		})
+		b.Run(fmt.Sprintf("recurrence/%d", modulus), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				util.matmulRecurrence(state, rand)
+			}
+		})
+	}
+}
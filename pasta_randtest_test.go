@@ -0,0 +1,28 @@
+package pasta
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/fedejinich/pasta-go/internal/randtest"
+)
+
+func TestKeystreamStatistics(t *testing.T) {
+	rng := rand.New(rand.NewSource(10))
+
+	for _, modulus := range []uint64{7, 65537, 8088322049, 1096486890805657601} {
+		key := randomVector(rng, int(Pasta4.SecretKeySize), modulus)
+		util := NewUtil(key, modulus, int(Pasta4.Rounds))
+
+		var samples []uint64
+		for block := uint64(0); block < 1024; block++ {
+			samples = append(samples, util.Keystream(DefaultNonce, block)...)
+		}
+
+		for _, r := range randtest.Suite(samples, modulus) {
+			if !r.Pass(randtest.DefaultAlpha) {
+				t.Errorf("p=%d: keystream fails %v", modulus, r)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package pasta
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/fedejinich/pasta-go/internal/pastaref"
+)
+
+// checkReference compares Util.Keystream against the specification
+// implementation of internal/pastaref.
+func checkReference(t *testing.T, key []uint64, modulus uint64, rounds int, nonce, blockCounter uint64) {
+	t.Helper()
+
+	util := NewUtil(key, modulus, rounds)
+	got := util.Keystream(nonce, blockCounter)
+	want := pastaref.Keystream(key, modulus, rounds, nonce, blockCounter)
+
+	if !equalSlices(got, want) {
+		t.Fatalf("p=%d t=%d rounds=%d nonce=%d block=%d: keystream differs from the reference",
+			modulus, len(key)/2, rounds, nonce, blockCounter)
+	}
+}
+
+func TestReferenceKeystream(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+
+	moduli := []uint64{7, 17, 65537, 8088322049, 1096486890805657601}
+	// random primes of every size up to 64 bits
+	for bits := 3; bits <= 64; bits += 5 {
+		prime, err := cryptoPrime(rng, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		moduli = append(moduli, prime)
+	}
+
+	for _, modulus := range moduli {
+		for _, size := range []int{2, 3, 8, 33, 64} {
+			rounds := 3 + rng.Intn(2)
+			key := randomVector(rng, 2*size, modulus)
+			checkReference(t, key, modulus, rounds, rng.Uint64(), uint64(rng.Intn(4)))
+		}
+	}
+
+	// the PASTA-3 and PASTA-4 presets at full size
+	key := randomVector(rng, int(Pasta3.SecretKeySize), 65537)
+	checkReference(t, key, 65537, int(Pasta3.Rounds), DefaultNonce, 0)
+	key = randomVector(rng, int(Pasta4.SecretKeySize), 1096486890805657601)
+	checkReference(t, key, 1096486890805657601, int(Pasta4.Rounds), DefaultNonce, 1)
+}
+
+func cryptoPrime(rng *rand.Rand, bits int) (uint64, error) {
+	for {
+		n := new(big.Int).Rand(rng, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+		n.SetBit(n, bits-1, 1)
+		if n.ProbablyPrime(20) {
+			return n.Uint64(), nil
+		}
+	}
+}
+
+func FuzzReferenceKeystream(f *testing.F) {
+	f.Add(int64(1), uint64(65537), uint8(4), uint8(3), uint64(DefaultNonce), uint64(0))
+	f.Add(int64(2), uint64(8088322049), uint8(16), uint8(4), uint64(0), uint64(3))
+	f.Add(int64(3), uint64(1096486890805657601), uint8(1), uint8(1), uint64(1<<63), uint64(1<<40))
+	f.Add(int64(4), uint64(7), uint8(9), uint8(2), uint64(42), uint64(7))
+
+	f.Fuzz(func(t *testing.T, seed int64, modulus uint64, size, rounds uint8, nonce, blockCounter uint64) {
+		if modulus < 2 || size == 0 || size > 64 || rounds > 6 {
+			t.Skip()
+		}
+
+		rng := rand.New(rand.NewSource(seed))
+		key := make([]uint64, 2*int(size))
+		for i := range key {
+			// unreduced keys are reduced by both implementations
+			key[i] = rng.Uint64()
+		}
+
+		checkReference(t, key, modulus, int(rounds), nonce, blockCounter)
+	})
+}
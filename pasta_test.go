@@ -719,7 +719,7 @@ func square(output *[]uint64, input []uint64, modulus uint64) {
 	}
 }
 
-func equalSlices(a, b []uint64) bool {
+func equalSlices[T Word](a, b []T) bool {
 	if len(a) != len(b) {
 		return false
 	}
@@ -0,0 +1,128 @@
+package pasta
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+func TestEncryptToMatchesEncrypt(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, modulus := range []uint64{65537, 1096486890805657601} {
+		secretKey := randomVector(rng, int(Pasta4.SecretKeySize), modulus)
+		plaintext := randomVector(rng, 3*int(Pasta4.PlainSize)+5, modulus)
+		pasta := NewPasta(secretKey, modulus, Pasta4)
+
+		expected := pasta.Encrypt(plaintext)
+
+		dst := make([]uint64, len(plaintext)+3)
+		pasta.EncryptTo(dst, plaintext)
+		if !equalSlices(dst[:len(plaintext)], expected) {
+			t.Errorf("modulus %d: EncryptTo differs from Encrypt", modulus)
+		}
+
+		// in place
+		buf := make([]uint64, len(plaintext))
+		copy(buf, plaintext)
+		pasta.EncryptTo(buf, buf)
+		if !equalSlices(buf, expected) {
+			t.Errorf("modulus %d: in-place EncryptTo differs from Encrypt", modulus)
+		}
+		pasta.DecryptTo(buf, buf)
+		if !equalSlices(buf, plaintext) {
+			t.Errorf("modulus %d: in-place DecryptTo differs from the plaintext", modulus)
+		}
+	}
+}
+
+func TestEncryptAbove63Bits(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+
+	// 2^64 - 59, the largest 64-bit prime: c + k overflows a uint64
+	modulus := uint64(18446744073709551557)
+	pasta := NewPasta(randomVector(rng, int(Pasta4.SecretKeySize), modulus), modulus, Pasta4)
+	plaintext := randomVector(rng, 2*int(Pasta4.PlainSize)+3, modulus)
+
+	ciphertext := pasta.Encrypt(plaintext)
+	ks := pasta.Keystream(DefaultNonce, 0)
+	for i := 0; i < int(Pasta4.PlainSize); i++ {
+		if ciphertext[i] != addMod(plaintext[i], ks[i], modulus) {
+			t.Fatalf("ciphertext[%d] = %d, expected %d", i, ciphertext[i], addMod(plaintext[i], ks[i], modulus))
+		}
+	}
+	if !equalSlices(pasta.Decrypt(ciphertext), plaintext) {
+		t.Errorf("Decrypt does not invert Encrypt")
+	}
+}
+
+func TestEncryptToDoesNotAllocate(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	secretKey := randomVector(rng, int(Pasta4.SecretKeySize), 65537)
+	buf := randomVector(rng, 100, 65537)
+	pasta := NewPasta(secretKey, 65537, Pasta4)
+
+	// warm up the cached Util and its scratch arenas
+	for i := 0; i < 4; i++ {
+		pasta.EncryptTo(buf, buf)
+	}
+	// the cache survives garbage collection
+	runtime.GC()
+
+	allocs := testing.AllocsPerRun(20, func() {
+		pasta.EncryptTo(buf, buf)
+		pasta.DecryptTo(buf, buf)
+	})
+	if allocs != 0 {
+		t.Errorf("EncryptTo/DecryptTo allocate %f times per run", allocs)
+	}
+}
+
+func TestEncryptToPanicsOnShortOutput(t *testing.T) {
+	pasta := NewPasta(make([]uint64, Pasta4.SecretKeySize), 65537, Pasta4)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	pasta.EncryptTo(make([]uint64, 2), make([]uint64, 3))
+}
+
+func TestEncryptToFollowsFieldChanges(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	plaintext := randomVector(rng, 40, 65537)
+	pasta := NewPasta(randomVector(rng, int(Pasta4.SecretKeySize), 65537), 65537, Pasta4)
+	dst := make([]uint64, len(plaintext))
+	pasta.EncryptTo(dst, plaintext)
+
+	// a new key must not reuse the cached Util
+	pasta.SecretKey = randomVector(rng, int(Pasta4.SecretKeySize), 65537)
+	pasta.EncryptTo(dst, plaintext)
+
+	fresh := NewPasta(pasta.SecretKey, 65537, Pasta4)
+	if !equalSlices(dst, fresh.Encrypt(plaintext)) {
+		t.Errorf("EncryptTo used a stale key")
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	rng := rand.New(rand.NewSource(4))
+	secretKey := randomVector(rng, int(Pasta4.SecretKeySize), 65537)
+	plaintext := randomVector(rng, 1024, 65537)
+	pasta := NewPasta(secretKey, 65537, Pasta4)
+
+	b.Run("Encrypt", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			pasta.Encrypt(plaintext)
+		}
+	})
+	b.Run("EncryptTo", func(b *testing.B) {
+		b.ReportAllocs()
+		dst := make([]uint64, len(plaintext))
+		for i := 0; i < b.N; i++ {
+			pasta.EncryptTo(dst, plaintext)
+		}
+	})
+}
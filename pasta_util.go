@@ -1,31 +1,103 @@
 package pasta
 
-import "C"
 import (
 	"encoding/binary"
 	"math/big"
-
-	"golang.org/x/crypto/sha3"
+	"math/bits"
 )
 
 const PastaT = PlaintextSize // plain text size
 
 type SecretKey []uint64
-type Block [PastaT]uint64
+type Block []uint64
+
+// UtilOf computes the PASTA keystream on field elements stored as T.
+type UtilOf[T Word] struct {
+	sampler
+	field_ Field[T]
+
+	secretKey_       []T
+	state1_, state2_ []T
+
+	// workspaces for the random matrix seeds and for EncryptTo/DecryptTo
+	rand_, ks_ []T
+
+	// scratch arenas of matmulRecurrence, see alloc
+	scratch_    []T
+	scratchPos_ int
+	limbs_      []uint32
+	limbsPos_   int
+
+	// t is the size of each state half, half of the secret key size
+	t, rounds int
+
+	tracer_ Tracer
+}
 
-type Util struct {
-	shake128_ sha3.ShakeHash
+type Util = UtilOf[uint64]
 
-	secretKey_       SecretKey
-	state1_, state2_ Block
+// xofBufferSize is a multiple of both 8 and the SHAKE128 rate (168 bytes)
+const xofBufferSize = 4 * 168
 
-	maxPrimeSize, modulus uint64
+// sampler squeezes field elements mod modulus out of an XOF (SHAKE128 by
+// default) seeded with (nonce, blockCounter). The XOF output is squeezed
+// xofBufferSize bytes at a time, which yields the same byte stream as
+// squeezing 8 bytes per element.
+type sampler struct {
+	newXOF_ XOFFactory
+	xof_    XOF
 
-	rounds int
+	seed_ [16]byte
+	buf_  [xofBufferSize]byte
+	pos_  int
+
+	maxPrimeSize uint64
+
+	field
 }
 
 func NewUtil(secretKey []uint64, modulus uint64, rounds int) Util {
-	var state1, state2 [PastaT]uint64
+	return NewUtilWithXOF(secretKey, modulus, rounds, NewShake128)
+}
+
+// NewUtilWithXOF is NewUtil with the round material squeezed out of newXOF
+// instead of SHAKE128.
+func NewUtilWithXOF(secretKey []uint64, modulus uint64, rounds int, newXOF XOFFactory) Util {
+	return NewUtilOf(secretKey, modulus, rounds, newXOF)
+}
+
+// NewUtilOf is NewUtilWithXOF for elements stored as T. It panics if the
+// modulus does not fit in T.
+func NewUtilOf[T Word](secretKey []T, modulus uint64, rounds int, newXOF XOFFactory) UtilOf[T] {
+	t := len(secretKey) / 2
+
+	field, err := NewField[T](modulus)
+	if err != nil {
+		panic(err)
+	}
+
+	return UtilOf[T]{
+		newSampler(modulus, newXOF),
+		field,
+		secretKey,
+		make([]T, t),
+		make([]T, t),
+		make([]T, t),
+		make([]T, t),
+		nil,
+		0,
+		nil,
+		0,
+		t,
+		rounds,
+		nil,
+	}
+}
+
+func newSampler(modulus uint64, newXOF XOFFactory) sampler {
+	if newXOF == nil {
+		newXOF = NewShake128
+	}
 
 	p := modulus
 	maxPrimeSize := uint64(0)
@@ -35,24 +107,33 @@ func NewUtil(secretKey []uint64, modulus uint64, rounds int) Util {
 	}
 	maxPrimeSize = (1 << maxPrimeSize) - 1
 
-	return Util{
+	return sampler{
+		newXOF,
 		nil,
-		secretKey,
-		state1,
-		state2,
+		[16]byte{},
+		[xofBufferSize]byte{},
+		xofBufferSize,
 		maxPrimeSize,
-		modulus,
-		rounds,
+		newField(modulus),
 	}
 }
 
-func (p *Util) Keystream(nonce uint64, blockCounter uint64) Block {
+func (p *UtilOf[T]) Keystream(nonce uint64, blockCounter uint64) []T {
+	ks := make([]T, p.t)
+	p.KeystreamTo(ks, nonce, blockCounter)
+
+	return ks
+}
+
+// KeystreamTo writes the keystream of block blockCounter into the first t
+// elements of dst.
+func (p *UtilOf[T]) KeystreamTo(dst []T, nonce uint64, blockCounter uint64) {
 	p.initShake(nonce, blockCounter)
 
 	// init state
-	for i := 0; i < PastaT; i++ {
-		p.state1_[i] = p.secretKey_[i]
-		p.state2_[i] = p.secretKey_[PastaT+i]
+	for i := 0; i < p.t; i++ {
+		p.state1_[i] = p.field_.Reduce(uint64(p.secretKey_[i]))
+		p.state2_[i] = p.field_.Reduce(uint64(p.secretKey_[p.t+i]))
 	}
 
 	for r := 0; r < p.rounds; r++ {
@@ -60,41 +141,64 @@ func (p *Util) Keystream(nonce uint64, blockCounter uint64) Block {
 	}
 
 	// final affine with mixing afterwards
-	p.linearLayer()
+	p.linearLayer(p.rounds)
 
-	return p.state1_
+	copy(dst[:p.t], p.state1_)
 }
 
-func (p *Util) initShake(nonce, blockCounter uint64) {
+// initShake reuses the previous XOF when it can be reset, as SHAKE can.
+func (p *sampler) initShake(nonce, blockCounter uint64) {
+	resetter, ok := p.xof_.(interface{ Reset() })
+	if !ok {
+		p.xof_ = newSeededXOF(p.newXOF_, nonce, blockCounter)
+		p.pos_ = xofBufferSize
+		return
+	}
+
+	resetter.Reset()
+	binary.BigEndian.PutUint64(p.seed_[:8], nonce)
+	binary.BigEndian.PutUint64(p.seed_[8:], blockCounter)
+	if _, err := p.xof_.Write(p.seed_[:]); err != nil {
+		panic("XOF update failed")
+	}
+	p.pos_ = xofBufferSize
+}
+
+// newSeededXOF absorbs the big-endian (nonce, blockCounter) seed.
+func newSeededXOF(newXOF XOFFactory, nonce, blockCounter uint64) XOF {
 	seed := make([]byte, 16)
 
 	binary.BigEndian.PutUint64(seed[:8], nonce)
 	binary.BigEndian.PutUint64(seed[8:], blockCounter)
 
-	shake := sha3.NewShake128()
-	if _, err := shake.Write(seed); err != nil {
-		panic("SHAKE128 update failed")
+	xof := newXOF()
+	if _, err := xof.Write(seed); err != nil {
+		panic("XOF update failed")
 	}
 
-	p.shake128_ = shake
+	return xof
 }
 
-func (p *Util) getRandomVector(allowZero bool) []uint64 {
-	rc := make([]uint64, PastaT)
-	for i := uint16(0); i < uint16(PastaT); i++ {
-		rc[i] = p.generateRandomFieldElement(allowZero)
+// getRandomVector fills and returns the rand_ workspace, which is
+// overwritten by the next call.
+func (p *UtilOf[T]) getRandomVector(allowZero bool) []T {
+	for i := 0; i < p.t; i++ {
+		p.rand_[i] = T(p.generateRandomFieldElement(allowZero))
 	}
-	return rc
+	return p.rand_
 }
 
-func (p *Util) generateRandomFieldElement(allowZero bool) uint64 {
-	var randomBytes [8]byte
+func (p *sampler) generateRandomFieldElement(allowZero bool) uint64 {
 	for {
-		if _, err := p.shake128_.Read(randomBytes[:]); err != nil {
-			panic("SHAKE128 squeeze failed")
+		if p.pos_ == xofBufferSize {
+			if _, err := p.xof_.Read(p.buf_[:]); err != nil {
+				panic("XOF squeeze failed")
+			}
+			p.pos_ = 0
 		}
 
-		ele := binary.BigEndian.Uint64(randomBytes[:]) & p.maxPrimeSize
+		ele := binary.BigEndian.Uint64(p.buf_[p.pos_:p.pos_+8]) & p.maxPrimeSize
+		p.pos_ += 8
 
 		if !allowZero && ele == 0 {
 			continue
@@ -107,145 +211,135 @@ func (p *Util) generateRandomFieldElement(allowZero bool) uint64 {
 }
 
 // The r-round Pasta construction to generate the keystream KN,i for block i under nonce N with affine layers Aj.
-func (p *Util) round(r int) {
+func (p *UtilOf[T]) round(r int) {
 	// Ai
-	p.linearLayer()
+	p.linearLayer(r)
 
 	// S(x) or S'(x)
 	if r == int(p.rounds)-1 {
-		p.sboxCube(&p.state1_)
-		p.sboxCube(&p.state2_)
+		p.sboxCube(p.state1_)
+		p.trace(r, StepSBox, 1, nil)
+		p.sboxCube(p.state2_)
+		p.trace(r, StepSBox, 2, nil)
 	} else {
-		p.sboxFeistel(&p.state1_)
-		p.sboxFeistel(&p.state2_)
+		p.sboxFeistel(p.state1_)
+		p.trace(r, StepSBox, 1, nil)
+		p.sboxFeistel(p.state2_)
+		p.trace(r, StepSBox, 2, nil)
 	}
 }
 
-// Aij(y) = Mij X y + cij
-func (p *Util) linearLayer() {
-	p.matmul(&p.state1_)
-	p.matmul(&p.state2_)
+// Aij(y) = Mij X y + cij, for round r or the final affine layer r = rounds
+func (p *UtilOf[T]) linearLayer(r int) {
+	p.trace(r, StepMatmul, 1, p.matmul(p.state1_))
+	p.trace(r, StepMatmul, 2, p.matmul(p.state2_))
 
-	p.addRc(&p.state1_)
-	p.addRc(&p.state2_)
+	p.trace(r, StepAddRC, 1, p.addRc(p.state1_))
+	p.trace(r, StepAddRC, 2, p.addRc(p.state2_))
 
 	p.mix()
+	p.trace(r, StepMix, 0, nil)
 }
 
-// Mij X y
-func (p *Util) matmul(state *Block) {
-	var newState Block
-
+// Mij X y, returning the first row of Mij
+func (p *UtilOf[T]) matmul(state []T) []T {
 	rand := p.getRandomVector(false)
+	p.matmulRecurrence(state, rand)
+	return rand
+}
+
+// matmulDense materializes every row of Mij with calculateRow, O(t^2).
+func (p *UtilOf[T]) matmulDense(state []T, rand []T) {
+	newState := make([]T, p.t)
+
 	currRow := rand
 
-	for i := 0; i < PastaT; i++ {
-		for j := 0; j < PastaT; j++ {
+	for i := 0; i < p.t; i++ {
+		for j := 0; j < p.t; j++ {
 			mult := new(big.Int).Mul(
 				big.NewInt(int64(currRow[j])),
 				big.NewInt(int64(state[j])),
 			)
 			modulus := big.NewInt(int64(p.modulus))
 			mult.Mod(mult, modulus)
-			newState[i] = (newState[i] + mult.Uint64()) % p.modulus
+			newState[i] = p.field_.Add(newState[i], T(mult.Uint64()))
 		}
-		if i != PastaT-1 {
+		if i != p.t-1 {
 			currRow = p.calculateRow(currRow, rand)
 		}
 	}
-	*state = newState
+	copy(state, newState)
 }
 
-// + cij
-func (p *Util) addRc(state *Block) {
-	for i := 0; i < PastaT; i++ {
-		randomFE := p.generateRandomFieldElement(true)
-
-		currentState := big.NewInt(int64(state[i]))
-		randomFEInt := big.NewInt(int64(randomFE))
-
-		modulus := big.NewInt(int64(p.modulus))
-		currentState.Add(currentState, randomFEInt)
-		currentState.Mod(currentState, modulus)
-
-		state[i] = currentState.Uint64()
+// + cij, returning cij
+func (p *UtilOf[T]) addRc(state []T) []T {
+	rc := p.getRandomVector(true)
+	for i := 0; i < p.t; i++ {
+		state[i] = p.field_.Add(state[i], rc[i])
 	}
+	return rc
 }
 
 // [S(x)]i = (x)3
-func (p *Util) sboxCube(state *Block) {
-	for i := 0; i < PastaT; i++ {
-		currentState := big.NewInt(int64(state[i]))
-		modulus := big.NewInt(int64(p.modulus))
-
-		square := new(big.Int).Mul(currentState, currentState)
-		square.Mod(square, modulus)
-		cube := square.Mul(square, currentState)
-
-		state[i] = cube.Mod(cube, modulus).Uint64()
+func (p *UtilOf[T]) sboxCube(state []T) {
+	for i := 0; i < p.t; i++ {
+		square := p.field_.Mul(state[i], state[i])
+		state[i] = p.field_.Mul(square, state[i])
 	}
 }
 
 // S'(x) = x + (rot(-1)(x) . m)^2
-func (p *Util) sboxFeistel(state *Block) {
-	pastaP := big.NewInt(int64(p.modulus))
-	var newState Block
-	newState[0] = state[0]
-
-	for i := 1; i < PastaT; i++ {
-		stateBig := big.NewInt(int64(state[i-1]))
-
-		square := new(big.Int).Mul(stateBig, stateBig)
-		cube := square.Mod(square, pastaP)
-		cubeAdd := cube.Add(cube, big.NewInt(int64(state[i])))
-		newElem := cubeAdd.Mod(cubeAdd, pastaP)
-
-		newState[i] = newElem.Uint64()
+func (p *UtilOf[T]) sboxFeistel(state []T) {
+	// right to left, so state[i-1] is still the input
+	for i := p.t - 1; i > 0; i-- {
+		square := p.field_.Mul(state[i-1], state[i-1])
+		state[i] = p.field_.Add(state[i], square)
 	}
-
-	*state = newState
 }
 
-func (p *Util) calculateRow(prevRow, firstRow []uint64) []uint64 {
-	out := make([]uint64, PastaT)
-
-	prevRowLast := big.NewInt(int64(prevRow[PastaT-1]))
+func (p *UtilOf[T]) calculateRow(prevRow, firstRow []T) []T {
+	out := make([]T, p.t)
 
-	for j := 0; j < PastaT; j++ {
-		firstRowVal := big.NewInt(int64(firstRow[j]))
-
-		tmp := new(big.Int).Mul(firstRowVal, prevRowLast)
-		modulus := big.NewInt(int64(p.modulus))
-		tmp.Mod(tmp, modulus)
+	prevRowLast := prevRow[p.t-1]
 
+	for j := 0; j < p.t; j++ {
+		out[j] = p.field_.Mul(firstRow[j], prevRowLast)
 		if j > 0 {
-			prevRowVal := big.NewInt(int64(prevRow[j-1]))
-			tmp.Add(tmp, prevRowVal)
-			tmp.Mod(tmp, modulus)
+			out[j] = p.field_.Add(out[j], prevRow[j-1])
 		}
-
-		out[j] = tmp.Uint64()
 	}
 
 	return out
 }
 
-func (p *Util) mix() {
-	for i := 0; i < PastaT; i++ {
-		pastaP := big.NewInt(int64(p.modulus))
-		state1 := big.NewInt(int64(p.state1_[i]))
-		state2 := big.NewInt(int64(p.state2_[i]))
+func (p *UtilOf[T]) mix() {
+	for i := 0; i < p.t; i++ {
+		sum := p.field_.Add(p.state1_[i], p.state2_[i])
 
-		sum := new(big.Int).Add(state1, state2)
-		sum = sum.Mod(sum, pastaP)
-
-		sum1 := new(big.Int).Add(state1, sum)
-		sum1 = sum1.Mod(sum1, pastaP)
+		p.state1_[i] = p.field_.Add(p.state1_[i], sum)
+		p.state2_[i] = p.field_.Add(p.state2_[i], sum)
+	}
+}
 
-		sum2 := new(big.Int).Add(state2, sum)
-		sum2 = sum2.Mod(sum2, pastaP)
+// a + b mod modulus, for a, b < modulus
+func addMod(a, b, modulus uint64) uint64 {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 || sum >= modulus {
+		sum -= modulus
+	}
+	return sum
+}
 
-		p.state1_[i] = sum1.Uint64()
-		p.state2_[i] = sum2.Uint64()
+// a - b mod modulus, for a, b < modulus
+func subMod(a, b, modulus uint64) uint64 {
+	if a >= b {
+		return a - b
 	}
+	return a + (modulus - b)
+}
+
+// a * b mod modulus, for a, b < modulus
+func mulMod(a, b, modulus uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, modulus)
 }
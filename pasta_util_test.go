@@ -0,0 +1,82 @@
+package pasta
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func TestBufferedSamplerMatchesUnbuffered(t *testing.T) {
+	for _, modulus := range []uint64{7, 65537, 8088322049, 1096486890805657601} {
+		s := newSampler(modulus, nil)
+		s.initShake(DefaultNonce, 3)
+
+		// one 8-byte squeeze per candidate, as before buffering
+		shake := sha3.NewShake128()
+		seed := make([]byte, 16)
+		binary.BigEndian.PutUint64(seed[:8], DefaultNonce)
+		binary.BigEndian.PutUint64(seed[8:], 3)
+		shake.Write(seed)
+		next := func(allowZero bool) uint64 {
+			var randomBytes [8]byte
+			for {
+				shake.Read(randomBytes[:])
+				ele := binary.BigEndian.Uint64(randomBytes[:]) & s.maxPrimeSize
+				if (allowZero || ele != 0) && ele < modulus {
+					return ele
+				}
+			}
+		}
+
+		for i := 0; i < 5000; i++ {
+			allowZero := i%3 != 0
+			if got, expected := s.generateRandomFieldElement(allowZero), next(allowZero); got != expected {
+				t.Fatalf("modulus %d, element %d: got %d, expected %d", modulus, i, got, expected)
+			}
+		}
+	}
+}
+
+func TestSamplerDoesNotAllocate(t *testing.T) {
+	s := newSampler(65537, nil)
+	s.initShake(DefaultNonce, 0)
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		s.generateRandomFieldElement(false)
+	})
+	if allocs != 0 {
+		t.Errorf("generateRandomFieldElement allocates %f times per call", allocs)
+	}
+}
+
+func BenchmarkKeystream(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+
+	for name, params := range map[string]Params{"pasta3": Pasta3, "pasta4": Pasta4} {
+		for _, modulus := range []uint64{65537, 1096486890805657601} {
+			secretKey := randomVector(rng, int(params.SecretKeySize), modulus)
+			b.Run(fmt.Sprintf("%s/%dbit", name, bits.Len64(modulus)), func(b *testing.B) {
+				// throughput is reported in bytes of 64-bit keystream elements
+				b.SetBytes(int64(8 * params.PlainSize))
+				util := NewUtil(secretKey, modulus, int(params.Rounds))
+				for i := 0; i < b.N; i++ {
+					util.Keystream(DefaultNonce, uint64(i))
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkGenerateRandomFieldElement(b *testing.B) {
+	s := newSampler(65537, nil)
+	s.initShake(DefaultNonce, 0)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		s.generateRandomFieldElement(true)
+	}
+}
@@ -0,0 +1,151 @@
+package pasta
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// RNSPasta encrypts vectors mod Q = p_0 * ... * p_k-1 as one PASTA
+// ciphertext per prime, each under its own key share. Plaintexts are
+// reconstructed from the residues with the CRT.
+type RNSPasta struct {
+	Ciphers []Pasta
+
+	// CRT basis: Q, Q/p_i and (Q/p_i)^-1 mod p_i
+	modulus *big.Int
+	basis   []*big.Int
+	inverse []*big.Int
+}
+
+// NewRNSPasta builds one Pasta per prime with secretKeys[i]. The primes
+// must be pairwise distinct and cipherParams valid PASTA params.
+func NewRNSPasta(primes []uint64, secretKeys [][]uint64, cipherParams Params) (RNSPasta, error) {
+	if len(primes) == 0 {
+		return RNSPasta{}, fmt.Errorf("rns: no primes")
+	}
+	if len(secretKeys) != len(primes) {
+		return RNSPasta{}, fmt.Errorf("rns: %d secret keys for %d primes", len(secretKeys), len(primes))
+	}
+	if err := validatePastaParams(cipherParams); err != nil {
+		return RNSPasta{}, fmt.Errorf("rns: %w", err)
+	}
+
+	ciphers := make([]Pasta, len(primes))
+	for i, p := range primes {
+		if !new(big.Int).SetUint64(p).ProbablyPrime(20) {
+			return RNSPasta{}, fmt.Errorf("rns: modulus %d is not prime", p)
+		}
+		for _, q := range primes[:i] {
+			if q == p {
+				return RNSPasta{}, fmt.Errorf("rns: prime %d appears twice", p)
+			}
+		}
+		if uint64(len(secretKeys[i])) != cipherParams.SecretKeySize {
+			return RNSPasta{}, fmt.Errorf("rns: secret key %d has %d elements, expected %d",
+				i, len(secretKeys[i]), cipherParams.SecretKeySize)
+		}
+		ciphers[i] = NewPasta(secretKeys[i], p, cipherParams)
+	}
+
+	modulus := big.NewInt(1)
+	for _, p := range primes {
+		modulus.Mul(modulus, new(big.Int).SetUint64(p))
+	}
+
+	basis := make([]*big.Int, len(primes))
+	inverse := make([]*big.Int, len(primes))
+	for i, p := range primes {
+		bigP := new(big.Int).SetUint64(p)
+		basis[i] = new(big.Int).Div(modulus, bigP)
+		inverse[i] = new(big.Int).ModInverse(new(big.Int).Mod(basis[i], bigP), bigP)
+	}
+
+	rns := RNSPasta{
+		ciphers,
+		modulus,
+		basis,
+		inverse,
+	}
+
+	return rns, nil
+}
+
+// Modulus returns Q, the product of the primes.
+func (r *RNSPasta) Modulus() *big.Int {
+	return new(big.Int).Set(r.modulus)
+}
+
+// ToResidues returns, for every prime p_i, the values mod p_i.
+func (r *RNSPasta) ToResidues(values []*big.Int) [][]uint64 {
+	residues := make([][]uint64, len(r.Ciphers))
+	tmp := new(big.Int)
+	for i, c := range r.Ciphers {
+		p := new(big.Int).SetUint64(c.Modulus)
+		residues[i] = make([]uint64, len(values))
+		for j, v := range values {
+			residues[i][j] = tmp.Mod(v, p).Uint64()
+		}
+	}
+	return residues
+}
+
+// FromResidues reconstructs values mod Q from their residues with the CRT.
+func (r *RNSPasta) FromResidues(residues [][]uint64) ([]*big.Int, error) {
+	if err := r.checkResidues(residues); err != nil {
+		return nil, err
+	}
+
+	values := make([]*big.Int, len(residues[0]))
+	tmp := new(big.Int)
+	for j := range values {
+		values[j] = new(big.Int)
+		for i := range r.Ciphers {
+			// x = sum (x_i (Q/p_i)^-1 mod p_i) Q/p_i mod Q
+			tmp.SetUint64(residues[i][j])
+			tmp.Mul(tmp, r.inverse[i])
+			tmp.Mod(tmp, new(big.Int).SetUint64(r.Ciphers[i].Modulus))
+			tmp.Mul(tmp, r.basis[i])
+			values[j].Add(values[j], tmp)
+		}
+		values[j].Mod(values[j], r.modulus)
+	}
+
+	return values, nil
+}
+
+// Encrypt encrypts plaintext mod Q under nonce, returning one ciphertext
+// per prime. A nonce must not be reused under the same key shares.
+func (r *RNSPasta) Encrypt(plaintext []*big.Int, nonce uint64) [][]uint64 {
+	residues := r.ToResidues(plaintext)
+	for i := range r.Ciphers {
+		residues[i] = r.Ciphers[i].EncryptWithNonce(residues[i], nonce)
+	}
+	return residues
+}
+
+// Decrypt decrypts the per-prime ciphertexts encrypted under nonce and
+// recombines them mod Q.
+func (r *RNSPasta) Decrypt(ciphertext [][]uint64, nonce uint64) ([]*big.Int, error) {
+	if err := r.checkResidues(ciphertext); err != nil {
+		return nil, err
+	}
+
+	residues := make([][]uint64, len(r.Ciphers))
+	for i := range r.Ciphers {
+		residues[i] = r.Ciphers[i].DecryptWithNonce(ciphertext[i], nonce)
+	}
+	return r.FromResidues(residues)
+}
+
+func (r *RNSPasta) checkResidues(residues [][]uint64) error {
+	if len(residues) != len(r.Ciphers) {
+		return fmt.Errorf("rns: %d residue vectors for %d primes", len(residues), len(r.Ciphers))
+	}
+	for i := range residues {
+		if len(residues[i]) != len(residues[0]) {
+			return fmt.Errorf("rns: residue vector %d has %d elements, expected %d",
+				i, len(residues[i]), len(residues[0]))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package pasta
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestRNSPastaEncryptionDecryption(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	primes := []uint64{65537, 8088322049, 1096486890805657601}
+
+	secretKeys := make([][]uint64, len(primes))
+	for i, p := range primes {
+		secretKeys[i] = randomVector(rng, int(Pasta4.SecretKeySize), p)
+	}
+
+	rns, err := NewRNSPasta(primes, secretKeys, Pasta4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := randomBigVector(rng, 50, rns.Modulus())
+	ciphertext := rns.Encrypt(plaintext, 42)
+	decrypted, err := rns.Decrypt(ciphertext, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range plaintext {
+		if decrypted[i].Cmp(plaintext[i]) != 0 {
+			t.Errorf("decrypted[%d] = %v, expected %v", i, decrypted[i], plaintext[i])
+		}
+	}
+
+	// every residue ciphertext is the plain Pasta ciphertext under its nonce
+	residues := rns.ToResidues(plaintext)
+	expected := rns.Ciphers[1].EncryptWithNonce(residues[1], 42)
+	if !equalSlices(ciphertext[1], expected) {
+		t.Errorf("residue ciphertext differs from Pasta's")
+	}
+
+	other := rns.Encrypt(plaintext, 43)
+	if equalSlices(other[0], ciphertext[0]) {
+		t.Errorf("the nonce does not change the ciphertext")
+	}
+}
+
+func TestRNSPastaCRT(t *testing.T) {
+	rns, err := NewRNSPasta([]uint64{7, 11, 13}, [][]uint64{
+		make([]uint64, 64), make([]uint64, 64), make([]uint64, 64)}, Pasta4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := make([]*big.Int, 1001)
+	for i := range values {
+		values[i] = big.NewInt(int64(i))
+	}
+	reconstructed, err := rns.FromResidues(rns.ToResidues(values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range values {
+		if reconstructed[i].Cmp(values[i]) != 0 {
+			t.Errorf("reconstructed[%d] = %v", i, reconstructed[i])
+		}
+	}
+}
+
+func TestNewRNSPastaErrors(t *testing.T) {
+	key := make([]uint64, 64)
+
+	for _, primes := range [][]uint64{{7, 0}, {7, 1}, {7, 15}, {7, 7}} {
+		if _, err := NewRNSPasta(primes, [][]uint64{key, key}, Pasta4); err == nil {
+			t.Errorf("primes %v: expected an error", primes)
+		}
+	}
+	if _, err := NewRNSPasta([]uint64{7, 11}, [][]uint64{key}, Pasta4); err == nil {
+		t.Errorf("expected error for missing key")
+	}
+	if _, err := NewRNSPasta([]uint64{7}, [][]uint64{key[:10]}, Pasta4); err == nil {
+		t.Errorf("expected error for short key")
+	}
+	for _, params := range []Params{{64, 0, 0, 4}, {64, 33, 33, 4}, {64, 32, 32, 0}} {
+		if _, err := NewRNSPasta([]uint64{7, 11}, [][]uint64{key, key}, params); err == nil {
+			t.Errorf("params %v: expected an error", params)
+		}
+	}
+
+	rns, _ := NewRNSPasta([]uint64{7, 11}, [][]uint64{key, key}, Pasta4)
+	if _, err := rns.Decrypt([][]uint64{{1, 2}}, DefaultNonce); err == nil {
+		t.Errorf("expected error for missing residues")
+	}
+}
@@ -0,0 +1,86 @@
+package pasta
+
+import (
+	"crypto/rand"
+	"io"
+)
+
+// RubatoParams extends Params with the standard deviation of the discrete
+// Gaussian noise added to the keystream. SecretKeySize is the state size
+// n = v*v and PlainSize/CipherSize the truncated keystream length l.
+type RubatoParams struct {
+	Params
+	Sigma float64
+}
+
+var (
+	Rubato80S  = RubatoParams{Params{16, 12, 12, 2}, 1.6356}
+	Rubato80M  = RubatoParams{Params{36, 32, 32, 2}, 1.6356}
+	Rubato80L  = RubatoParams{Params{64, 60, 60, 2}, 1.6356}
+	Rubato128S = RubatoParams{Params{16, 12, 12, 5}, 10.4945}
+	Rubato128M = RubatoParams{Params{36, 32, 32, 3}, 10.4945}
+	Rubato128L = RubatoParams{Params{64, 60, 60, 2}, 10.4945}
+)
+
+// Rubato encryption is approximate: Decrypt(Encrypt(m)) = m + e mod Modulus,
+// where e is the discrete Gaussian noise added to every keystream element.
+// Callers are expected to encode their messages scaled by a factor much
+// larger than Sigma.
+type Rubato struct {
+	SecretKey    SecretKey
+	Modulus      uint64
+	CipherParams RubatoParams
+
+	// source of randomness for the noise, defaults to crypto/rand
+	Rand io.Reader
+}
+
+func NewRubato(secretKey []uint64, modulus uint64, cipherParams RubatoParams) Rubato {
+	rubato := Rubato{
+		secretKey,
+		modulus,
+		cipherParams,
+		rand.Reader,
+	}
+
+	return rubato
+}
+
+func (r *Rubato) Encrypt(plaintext []uint64) []uint64 {
+	return r.EncryptWithNonce(plaintext, DefaultNonce)
+}
+
+func (r *Rubato) Decrypt(ciphertext []uint64) []uint64 {
+	return r.DecryptWithNonce(ciphertext, DefaultNonce)
+}
+
+func (r *Rubato) EncryptWithNonce(plaintext []uint64, nonce uint64) []uint64 {
+	rubatoUtil := NewRubatoUtil(r.SecretKey, r.Modulus, r.CipherParams)
+	gaussian := NewGaussianSampler(r.CipherParams.Sigma, r.Rand)
+	keystream := func(nonce, blockCounter uint64) []uint64 {
+		return rubatoUtil.NoisyKeystream(nonce, blockCounter, gaussian)
+	}
+
+	return encryptBlocks(plaintext, nonce, r.CipherParams.PlainSize, r.Modulus, keystream)
+}
+
+// DecryptWithNonce removes the noiseless keystream, so the result still
+// carries the encryption noise.
+func (r *Rubato) DecryptWithNonce(ciphertext []uint64, nonce uint64) []uint64 {
+	rubatoUtil := NewRubatoUtil(r.SecretKey, r.Modulus, r.CipherParams)
+	return decryptBlocks(ciphertext, nonce, r.CipherParams.CipherSize, r.Modulus, rubatoUtil.Keystream)
+}
+
+// Keystream returns the noiseless keystream.
+func (r *Rubato) Keystream(nonce uint64, blockCounter uint64) []uint64 {
+	rubatoUtil := NewRubatoUtil(r.SecretKey, r.Modulus, r.CipherParams)
+	return rubatoUtil.Keystream(nonce, blockCounter)
+}
+
+func (r *Rubato) Params() Params {
+	return r.CipherParams.Params
+}
+
+func (r *Rubato) PlainModulus() uint64 {
+	return r.Modulus
+}
@@ -0,0 +1,118 @@
+package pasta
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+const RubatoTestModulus = 65929217 // 0x3ee0001
+
+func TestRubatoEncryptionDecryption(t *testing.T) {
+	presets := []RubatoParams{Rubato80S, Rubato80M, Rubato80L, Rubato128S, Rubato128M, Rubato128L}
+	rng := rand.New(rand.NewSource(1))
+
+	for _, params := range presets {
+		secretKey := randomVector(rng, int(params.SecretKeySize), RubatoTestModulus)
+		plaintext := randomVector(rng, 3*int(params.PlainSize)+1, RubatoTestModulus)
+
+		rubato := NewRubato(secretKey, RubatoTestModulus, params)
+		rubato.Rand = rng
+		ciphertext := rubato.Encrypt(plaintext)
+		decrypted := rubato.Decrypt(ciphertext)
+
+		bound := int64(math.Ceil(6 * params.Sigma))
+		for i := range plaintext {
+			e := int64(decrypted[i]) - int64(plaintext[i])
+			if e > RubatoTestModulus/2 {
+				e -= RubatoTestModulus
+			} else if e < -RubatoTestModulus/2 {
+				e += RubatoTestModulus
+			}
+			if e > bound || e < -bound {
+				t.Errorf("%v: noise %d at %d exceeds %d", params, e, i, bound)
+			}
+		}
+	}
+}
+
+func TestRubatoNoiselessRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	params := Rubato128S
+	params.Sigma = 0
+
+	secretKey := randomVector(rng, int(params.SecretKeySize), RubatoTestModulus)
+	plaintext := randomVector(rng, 50, RubatoTestModulus)
+
+	rubato := NewRubato(secretKey, RubatoTestModulus, params)
+	decrypted := rubato.Decrypt(rubato.Encrypt(plaintext))
+
+	if !equalSlices(decrypted, plaintext) {
+		t.Errorf("different plaintexts. decrypted(%d), plaintext(%d)", decrypted, plaintext)
+	}
+}
+
+func TestRubatoMixMatchesHera(t *testing.T) {
+	rubato := NewRubatoUtil(nil, RubatoTestModulus, Rubato80S)
+	hera := NewHeraUtil(nil, RubatoTestModulus, 5)
+	for i := 0; i < HeraT; i++ {
+		rubato.state_[i] = uint64(i*i + 7)
+		hera.state_[i] = uint64(i*i + 7)
+	}
+
+	rubato.mixColumns()
+	rubato.mixRows()
+	hera.mixColumns()
+	hera.mixRows()
+
+	if !equalSlices(rubato.state_, hera.state_[:]) {
+		t.Errorf("different states. rubato(%d), hera(%d)", rubato.state_, hera.state_)
+	}
+}
+
+func TestRubatoMixCirculants(t *testing.T) {
+	for _, params := range []RubatoParams{Rubato80S, Rubato80M, Rubato80L} {
+		rubato := NewRubatoUtil(nil, RubatoTestModulus, params)
+		v := rubato.v
+		c := rubatoMix[v]
+
+		x := make([]uint64, v)
+		for i := range x {
+			x[i] = uint64(3*i + 1)
+		}
+		copy(rubato.state_, x)
+		rubato.mixRows()
+
+		for i := 0; i < v; i++ {
+			want := uint64(0)
+			for k := 0; k < v; k++ {
+				want += c[k] * x[(i+k)%v]
+			}
+			if rubato.state_[i] != want%RubatoTestModulus {
+				t.Errorf("v=%d: row element %d = %d, want %d", v, i, rubato.state_[i], want)
+			}
+		}
+	}
+}
+
+func TestGaussianSampler(t *testing.T) {
+	sigma := 10.0
+	gaussian := NewGaussianSampler(sigma, rand.New(rand.NewSource(3)))
+
+	n := 100000
+	sum, sumSquares := 0.0, 0.0
+	for i := 0; i < n; i++ {
+		x := float64(gaussian.Sample())
+		sum += x
+		sumSquares += x * x
+	}
+
+	mean := sum / float64(n)
+	stddev := math.Sqrt(sumSquares/float64(n) - mean*mean)
+	if math.Abs(mean) > 0.2 {
+		t.Errorf("mean %f too far from 0", mean)
+	}
+	if math.Abs(stddev-sigma) > 0.2 {
+		t.Errorf("standard deviation %f too far from %f", stddev, sigma)
+	}
+}
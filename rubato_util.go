@@ -0,0 +1,209 @@
+package pasta
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+type RubatoUtil struct {
+	sampler
+
+	secretKey_ SecretKey
+	state_     []uint64
+
+	// first row of the circulant MixColumns/MixRows matrix, see rubatoMix
+	mix_ []uint64
+	x_   []uint64
+
+	// the state is a v x v matrix, n = v*v elements, truncated to l outputs
+	v, l   int
+	rounds int
+}
+
+// rubatoMix holds the first row of the circulant matrix of each state
+// size v of the Rubato specification.
+var rubatoMix = map[int][]uint64{
+	4: {2, 3, 1, 1},
+	6: {4, 2, 4, 3, 1, 1},
+	8: {5, 3, 4, 3, 6, 2, 1, 1},
+}
+
+func NewRubatoUtil(secretKey []uint64, modulus uint64, params RubatoParams) RubatoUtil {
+	n := int(params.SecretKeySize)
+	v := int(math.Sqrt(float64(n)))
+	if v*v != n {
+		panic("rubato state size must be a perfect square")
+	}
+	mix, ok := rubatoMix[v]
+	if !ok {
+		panic("rubato state size must be 16, 36 or 64")
+	}
+
+	return RubatoUtil{
+		newSampler(modulus, nil),
+		secretKey,
+		make([]uint64, n),
+		mix,
+		make([]uint64, v),
+		v,
+		int(params.PlainSize),
+		int(params.Rounds),
+	}
+}
+
+// Rubato[r] = Fin o RF[r-1] o ... o RF[1] o ARK applied to the constant
+// state (1, 2, ..., n), where RF = ARK o Feistel o MR o MC and
+// Fin = Tr o ARK o MR o MC o Feistel o MR o MC. The Gaussian noise (AGN)
+// is left to NoisyKeystream, since the homomorphic evaluator computes the
+// noiseless keystream.
+func (r *RubatoUtil) Keystream(nonce uint64, blockCounter uint64) []uint64 {
+	r.initShake(nonce, blockCounter)
+
+	// init state
+	for i := range r.state_ {
+		r.state_[i] = uint64(i+1) % r.modulus
+	}
+
+	r.addRoundKey()
+
+	for round := 1; round < r.rounds; round++ {
+		r.round()
+	}
+
+	// final round
+	r.mixColumns()
+	r.mixRows()
+	r.sboxFeistel()
+	r.mixColumns()
+	r.mixRows()
+	r.addRoundKey()
+
+	// Tr: truncate to l elements
+	ks := make([]uint64, r.l)
+	copy(ks, r.state_[:r.l])
+
+	return ks
+}
+
+// NoisyKeystream returns Keystream(nonce, blockCounter) with discrete
+// Gaussian noise added to every element (AGN).
+func (r *RubatoUtil) NoisyKeystream(nonce uint64, blockCounter uint64, gaussian *GaussianSampler) []uint64 {
+	ks := r.Keystream(nonce, blockCounter)
+	for i := range ks {
+		e := gaussian.Sample()
+		if e < 0 {
+			ks[i] = addMod(ks[i], r.modulus-uint64(-e)%r.modulus, r.modulus)
+		} else {
+			ks[i] = addMod(ks[i], uint64(e)%r.modulus, r.modulus)
+		}
+	}
+	return ks
+}
+
+// RF = ARK o Feistel o MR o MC
+func (r *RubatoUtil) round() {
+	r.mixColumns()
+	r.mixRows()
+	r.sboxFeistel()
+	r.addRoundKey()
+}
+
+// x + k . rc, with rc sampled from the XOF
+func (r *RubatoUtil) addRoundKey() {
+	for i := range r.state_ {
+		rc := r.generateRandomFieldElement(false)
+		r.state_[i] = addMod(r.state_[i], mulMod(r.secretKey_[i], rc, r.modulus), r.modulus)
+	}
+}
+
+// multiplies every column by circ(rubatoMix[v])
+func (r *RubatoUtil) mixColumns() {
+	idx := make([]int, r.v)
+	for col := 0; col < r.v; col++ {
+		for row := 0; row < r.v; row++ {
+			idx[row] = r.v*row + col
+		}
+		r.mixVector(idx)
+	}
+}
+
+// multiplies every row by circ(rubatoMix[v])
+func (r *RubatoUtil) mixRows() {
+	idx := make([]int, r.v)
+	for row := 0; row < r.v; row++ {
+		for col := 0; col < r.v; col++ {
+			idx[col] = r.v*row + col
+		}
+		r.mixVector(idx)
+	}
+}
+
+// y[i] = sum of c[k] x[i+k], indices taken mod v
+func (r *RubatoUtil) mixVector(idx []int) {
+	for i, j := range idx {
+		r.x_[i] = r.state_[j]
+	}
+
+	for i, j := range idx {
+		y := uint64(0)
+		for k, c := range r.mix_ {
+			y = addMod(y, mulMod(c, r.x_[(i+k)%r.v], r.modulus), r.modulus)
+		}
+		r.state_[j] = y
+	}
+}
+
+// y[0] = x[0], y[i] = x[i] + x[i-1]^2
+func (r *RubatoUtil) sboxFeistel() {
+	for i := len(r.state_) - 1; i > 0; i-- {
+		square := mulMod(r.state_[i-1], r.state_[i-1], r.modulus)
+		r.state_[i] = addMod(r.state_[i], square, r.modulus)
+	}
+}
+
+// GaussianSampler draws integers from the discrete Gaussian distribution
+// of standard deviation sigma, truncated at 6 sigma, by rejection sampling.
+type GaussianSampler struct {
+	sigma float64
+	bound int64
+	rand  io.Reader
+}
+
+func NewGaussianSampler(sigma float64, rand io.Reader) *GaussianSampler {
+	return &GaussianSampler{
+		sigma,
+		int64(math.Ceil(6 * sigma)),
+		rand,
+	}
+}
+
+func (g *GaussianSampler) Sample() int64 {
+	if g.bound == 0 {
+		return 0
+	}
+
+	width := uint64(2*g.bound + 1)
+	limit := math.MaxUint64 - math.MaxUint64%width
+	for {
+		u := g.uint64()
+		if u >= limit {
+			continue
+		}
+		x := int64(u%width) - g.bound
+
+		// accept with probability exp(-x^2 / 2 sigma^2)
+		p := math.Exp(-float64(x*x) / (2 * g.sigma * g.sigma))
+		if float64(g.uint64()>>11)/(1<<53) < p {
+			return x
+		}
+	}
+}
+
+func (g *GaussianSampler) uint64() uint64 {
+	var randomBytes [8]byte
+	if _, err := io.ReadFull(g.rand, randomBytes[:]); err != nil {
+		panic("gaussian sampler read failed")
+	}
+	return binary.BigEndian.Uint64(randomBytes[:])
+}
@@ -0,0 +1,154 @@
+package pasta
+
+import (
+	"fmt"
+	"io"
+)
+
+// Destroy zeroes the secret key, which is the caller's slice, and the key
+// and state buffers of the cached Utils. p must not be used afterwards.
+//
+// Utils held by calls still in flight are not reached: they share the
+// zeroed key and have their state wiped when returned, but may hold state
+// until then. Copies of p share the key and cache, and Destroy cannot
+// clear their SecretKey fields.
+func (p *PastaOf[T]) Destroy() {
+	wipe(p.SecretKey)
+	p.SecretKey = nil
+
+	if p.utils_ != nil {
+		for _, u := range p.utils_.drain() {
+			u.Destroy()
+		}
+	}
+}
+
+// Destroy zeroes the secret key, the state halves and every workspace that
+// held key-dependent values. p must not be used afterwards.
+func (p *UtilOf[T]) Destroy() {
+	wipe(p.secretKey_)
+	p.secretKey_ = nil
+	p.wipeState()
+}
+
+// wipeState zeroes everything Destroy does but the key.
+func (p *UtilOf[T]) wipeState() {
+	wipe(p.state1_)
+	wipe(p.state2_)
+	wipe(p.rand_)
+	wipe(p.ks_)
+	wipe(p.scratch_)
+	wipe(p.limbs_)
+
+	p.buf_ = [xofBufferSize]byte{}
+	p.pos_ = xofBufferSize
+	p.xof_ = nil
+}
+
+// Destroy zeroes the key elements.
+func (k *Key) Destroy() {
+	wipe(k.Elements)
+	k.Elements = nil
+}
+
+func wipe[T Word](s []T) {
+	for i := range s {
+		s[i] = 0
+	}
+}
+
+// redacted stands for n key elements in formatted output.
+func redacted(n int) string {
+	return fmt.Sprintf("[REDACTED %d elements]", n)
+}
+
+// formatRedacted prints s for %#v and String for every other verb, so
+// that no verb reaches the key elements.
+func formatRedacted(f fmt.State, verb rune, s fmt.Stringer, g fmt.GoStringer) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, g.GoString())
+		return
+	}
+	io.WriteString(f, s.String())
+}
+
+func (p PastaOf[T]) String() string {
+	return fmt.Sprintf("PASTA-%d mod %d, secret key %s",
+		p.CipherParams.Rounds, p.Modulus, redacted(len(p.SecretKey)))
+}
+
+func (p PastaOf[T]) GoString() string {
+	return fmt.Sprintf("%T{SecretKey:%s, Modulus:%d, CipherParams:%#v}",
+		p, redacted(len(p.SecretKey)), p.Modulus, p.CipherParams)
+}
+
+func (p PastaOf[T]) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, p, p)
+}
+
+func (p UtilOf[T]) String() string {
+	return fmt.Sprintf("PASTA util mod %d, t %d, %d rounds, secret key %s",
+		p.modulus, p.t, p.rounds, redacted(len(p.secretKey_)))
+}
+
+func (p UtilOf[T]) GoString() string {
+	return fmt.Sprintf("%T{secretKey_:%s, modulus:%d, t:%d, rounds:%d}",
+		p, redacted(len(p.secretKey_)), p.modulus, p.t, p.rounds)
+}
+
+func (p UtilOf[T]) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, p, p)
+}
+
+func (p BigPasta) String() string {
+	return fmt.Sprintf("PASTA-%d mod %v, secret key %s",
+		p.CipherParams.Rounds, p.Modulus, redacted(len(p.SecretKey)))
+}
+
+func (p BigPasta) GoString() string {
+	return fmt.Sprintf("pasta.BigPasta{SecretKey:%s, Modulus:%v, CipherParams:%#v}",
+		redacted(len(p.SecretKey)), p.Modulus, p.CipherParams)
+}
+
+func (p BigPasta) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, p, p)
+}
+
+func (p BigUtil) String() string {
+	return fmt.Sprintf("PASTA util mod %v, t %d, %d rounds, secret key %s",
+		p.modulus, p.t, p.rounds, redacted(len(p.secretKey_)))
+}
+
+func (p BigUtil) GoString() string {
+	return fmt.Sprintf("pasta.BigUtil{secretKey_:%s, modulus:%v, t:%d, rounds:%d}",
+		redacted(len(p.secretKey_)), p.modulus, p.t, p.rounds)
+}
+
+func (p BigUtil) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, p, p)
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("PASTA-%d key mod %d %s", k.Params.Rounds, k.Modulus, redacted(len(k.Elements)))
+}
+
+func (k Key) GoString() string {
+	return fmt.Sprintf("pasta.Key{Modulus:%d, Params:%#v, Elements:%s}",
+		k.Modulus, k.Params, redacted(len(k.Elements)))
+}
+
+func (k Key) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, k, k)
+}
+
+func (k SecretKey) String() string {
+	return redacted(len(k))
+}
+
+func (k SecretKey) GoString() string {
+	return "pasta.SecretKey" + redacted(len(k))
+}
+
+func (k SecretKey) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, k, k)
+}
@@ -0,0 +1,75 @@
+package pasta
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestRedactedFormatting(t *testing.T) {
+	modulus := uint64(65537)
+	key := make([]uint64, Pasta4.SecretKeySize)
+	for i := range key {
+		key[i] = 31337
+	}
+
+	pasta := NewPasta(key, modulus, Pasta4)
+	util := NewUtil(key, modulus, int(Pasta4.Rounds))
+	util.Keystream(DefaultNonce, 0)
+	bigPasta, err := NewBigPasta(toBig(key), new(big.Int).SetUint64(modulus), Pasta4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bigUtil, err := NewBigUtil(toBig(key), new(big.Int).SetUint64(modulus), int(Pasta4.Rounds), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bigUtil.Keystream(DefaultNonce, 0)
+	values := []interface{}{
+		pasta, &pasta, util, &util,
+		bigPasta, &bigPasta, bigUtil, &bigUtil,
+		Key{modulus, Pasta4, key}, SecretKey(key),
+		Hera{SecretKey: key, Modulus: modulus},
+	}
+
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s", "%d", "%x", "%q"} {
+		for _, v := range values {
+			out := fmt.Sprintf(verb, v)
+			if strings.Contains(out, "31337") || strings.Contains(out, "7a69") {
+				t.Errorf("%s of %T leaks the key: %s", verb, v, out)
+			}
+			if !strings.Contains(out, "REDACTED") {
+				t.Errorf("%s of %T: %s", verb, v, out)
+			}
+		}
+	}
+}
+
+func TestDestroy(t *testing.T) {
+	modulus := uint64(65537)
+	key := make([]uint64, Pasta4.SecretKeySize)
+	for i := range key {
+		key[i] = uint64(i + 1)
+	}
+
+	pasta := NewPasta(key, modulus, Pasta4)
+	data := make([]uint64, 100)
+	pasta.EncryptTo(data, data)
+
+	u := pasta.getUtil()
+	pasta.putUtil(u)
+
+	pasta.Destroy()
+
+	for _, buf := range [][]uint64{key, u.state1_, u.state2_, u.rand_, u.ks_, u.scratch_} {
+		for i, e := range buf {
+			if e != 0 {
+				t.Fatalf("element %d of a %d-element buffer not zeroed", i, len(buf))
+			}
+		}
+	}
+	if pasta.SecretKey != nil || u.secretKey_ != nil || u.xof_ != nil {
+		t.Error("references to the key or XOF remain")
+	}
+}
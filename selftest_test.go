@@ -0,0 +1,50 @@
+package pasta
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/fedejinich/pasta-go/internal/pastaref"
+)
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+
+	key := make([]uint64, Pasta4.SecretKeySize)
+	if _, err := NewPastaChecked(key, 65537, Pasta4); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := selfTestVectors[3].digest
+	selfTestVectors[3].digest = saved[1:] + "0"
+	defer func() { selfTestVectors[3].digest = saved }()
+
+	if err := runSelfTest(); err == nil {
+		t.Error("a wrong known answer passes the self-test")
+	}
+}
+
+// the embedded answers are those of the specification implementation
+func TestSelfTestVectorsMatchReference(t *testing.T) {
+	for _, v := range selfTestVectors {
+		key := make([]uint64, v.params.SecretKeySize)
+		for i := range key {
+			key[i] = (uint64(i)*uint64(i) + 1) % v.modulus
+		}
+
+		h := sha256.New()
+		var word [8]byte
+		for _, e := range pastaref.Keystream(key, v.modulus, int(v.params.Rounds), DefaultNonce, 1) {
+			binary.BigEndian.PutUint64(word[:], e)
+			h.Write(word[:])
+		}
+
+		if got := hex.EncodeToString(h.Sum(nil)); got != v.digest {
+			t.Errorf("%s mod %d: reference digest %s, embedded %s", v.name, v.modulus, got, v.digest)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package pasta
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// TraceStep names a step of the keystream computation.
+type TraceStep string
+
+const (
+	// Mij X y, Rand is the first row of Mij
+	StepMatmul TraceStep = "matmul"
+	// + cij, Rand is cij
+	StepAddRC TraceStep = "addrc"
+	// mixing of both halves
+	StepMix TraceStep = "mix"
+	// S(x) or S'(x)
+	StepSBox TraceStep = "sbox"
+)
+
+// TraceEvent is the state after one step of round Round; the final affine
+// layer is round rounds. Half is the state half the step applied to, or 0
+// for both.
+type TraceEvent struct {
+	Round int
+	Step  TraceStep
+	Half  int
+
+	State1, State2 []uint64
+	Rand           []uint64
+}
+
+// Tracer is called after every step of Util.Keystream. The slices of the
+// event are only valid during the call.
+type Tracer func(event TraceEvent)
+
+// SetTracer installs tracer, or removes it when nil.
+func (p *UtilOf[T]) SetTracer(tracer Tracer) {
+	p.tracer_ = tracer
+}
+
+func (p *UtilOf[T]) trace(round int, step TraceStep, half int, rand []T) {
+	if p.tracer_ == nil {
+		return
+	}
+
+	p.tracer_(TraceEvent{round, step, half, words(p.state1_), words(p.state2_), words(rand)})
+}
+
+// words views or converts a []T as []uint64.
+func words[T Word](s []T) []uint64 {
+	if s == nil {
+		return nil
+	}
+	if w, ok := any(s).([]uint64); ok {
+		return w
+	}
+
+	w := make([]uint64, len(s))
+	for i := range s {
+		w[i] = uint64(s[i])
+	}
+	return w
+}
+
+// Trace is a list of events, filled by Record.
+type Trace []TraceEvent
+
+// Record is a Tracer appending a copy of event to tr.
+func (tr *Trace) Record(event TraceEvent) {
+	event.State1 = append([]uint64(nil), event.State1...)
+	event.State2 = append([]uint64(nil), event.State2...)
+	if event.Rand != nil {
+		event.Rand = append([]uint64(nil), event.Rand...)
+	}
+	*tr = append(*tr, event)
+}
+
+// Trace files hold one event per line, its round, step and half followed by
+// the keyword-prefixed element lists, so that other implementations can
+// emit them easily:
+//
+//	0 matmul 1 state1 5 1 ... state2 7 2 ... rand 3 9 ...
+//	0 mix 0 state1 ... state2 ...
+//
+// Lines starting with '#' are comments.
+
+func WriteTrace(w io.Writer, tr Trace) error {
+	bw := bufio.NewWriter(w)
+
+	for _, e := range tr {
+		fmt.Fprintf(bw, "%d %s %d", e.Round, e.Step, e.Half)
+		writeWords(bw, "state1", e.State1)
+		writeWords(bw, "state2", e.State2)
+		if e.Rand != nil {
+			writeWords(bw, "rand", e.Rand)
+		}
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+func writeWords(w *bufio.Writer, name string, elements []uint64) {
+	w.WriteByte(' ')
+	w.WriteString(name)
+	for _, e := range elements {
+		w.WriteByte(' ')
+		w.WriteString(strconv.FormatUint(e, 10))
+	}
+}
+
+func ReadTrace(r io.Reader) (Trace, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+
+	var tr Trace
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected round, step and half", line)
+		}
+
+		round, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: round: %w", line, err)
+		}
+		half, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: half: %w", line, err)
+		}
+		event := TraceEvent{Round: round, Step: TraceStep(fields[1]), Half: half}
+
+		var list *[]uint64
+		for _, field := range fields[3:] {
+			switch field {
+			case "state1":
+				list = &event.State1
+			case "state2":
+				list = &event.State2
+			case "rand":
+				list = &event.Rand
+			default:
+				if list == nil {
+					return nil, fmt.Errorf("line %d: element before a list name", line)
+				}
+				e, err := strconv.ParseUint(field, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+				*list = append(*list, e)
+				continue
+			}
+			if *list == nil {
+				*list = []uint64{}
+			}
+		}
+
+		tr = append(tr, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return tr, nil
+}
+
+// TraceDiff locates the first divergence between two traces.
+type TraceDiff struct {
+	// index of the divergent event
+	Index int
+	// "step", "rand", "state1", "state2" or "length"
+	Field string
+	// index of the first different element, -1 for a length mismatch
+	Element int
+
+	Want, Got *TraceEvent
+}
+
+func (d *TraceDiff) String() string {
+	if d.Field == "length" {
+		return fmt.Sprintf("event %d: one trace ends early", d.Index)
+	}
+
+	where := fmt.Sprintf("event %d (round %d, %s, half %d)", d.Index, d.Want.Round, d.Want.Step, d.Want.Half)
+	switch {
+	case d.Field == "step":
+		return fmt.Sprintf("%s: got round %d, %s, half %d", where, d.Got.Round, d.Got.Step, d.Got.Half)
+	case d.Element < 0:
+		return fmt.Sprintf("%s: %s lengths differ", where, d.Field)
+	}
+
+	want, got := d.Want.field(d.Field), d.Got.field(d.Field)
+	return fmt.Sprintf("%s: %s[%d] = %d, want %d", where, d.Field, d.Element, got[d.Element], want[d.Element])
+}
+
+func (e *TraceEvent) field(name string) []uint64 {
+	switch name {
+	case "rand":
+		return e.Rand
+	case "state1":
+		return e.State1
+	}
+	return e.State2
+}
+
+// DiffTraces returns the first divergence of got from want, or nil if they
+// are equal. Randomness is compared before the states, so that a sampling
+// difference is not reported as a state difference.
+func DiffTraces(want, got Trace) *TraceDiff {
+	for i := range want {
+		if i == len(got) {
+			return &TraceDiff{i, "length", -1, &want[i], nil}
+		}
+
+		w, g := &want[i], &got[i]
+		if w.Round != g.Round || w.Step != g.Step || w.Half != g.Half {
+			return &TraceDiff{i, "step", -1, w, g}
+		}
+
+		for _, name := range []string{"rand", "state1", "state2"} {
+			a, b := w.field(name), g.field(name)
+			if len(a) != len(b) {
+				return &TraceDiff{i, name, -1, w, g}
+			}
+			for j := range a {
+				if a[j] != b[j] {
+					return &TraceDiff{i, name, j, w, g}
+				}
+			}
+		}
+	}
+
+	if len(got) > len(want) {
+		return &TraceDiff{len(want), "length", -1, nil, &got[len(want)]}
+	}
+
+	return nil
+}
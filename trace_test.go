@@ -0,0 +1,66 @@
+package pasta
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	modulus := uint64(65537)
+	key := randomVector(rng, int(Pasta4.SecretKeySize), modulus)
+
+	var tr Trace
+	util := NewUtil(key, modulus, int(Pasta4.Rounds))
+	util.SetTracer(tr.Record)
+	keystream := util.Keystream(7, 1)
+
+	// 7 steps per round and 5 in the final affine layer
+	if want := 7*int(Pasta4.Rounds) + 5; len(tr) != want {
+		t.Fatalf("%d events, want %d", len(tr), want)
+	}
+	last := tr[len(tr)-1]
+	if last.Step != StepMix || last.Round != int(Pasta4.Rounds) || !equalSlices(last.State1, keystream) {
+		t.Error("the last event is not the final mix giving the keystream")
+	}
+	if tr[0].Step != StepMatmul || len(tr[0].Rand) != len(key)/2 {
+		t.Error("the first event does not carry the matrix row")
+	}
+
+	// uint32 elements trace the same steps
+	var tr32 Trace
+	key32 := make([]uint32, len(key))
+	for i := range key {
+		key32[i] = uint32(key[i])
+	}
+	util32 := NewUtilOf(key32, modulus, int(Pasta4.Rounds), nil)
+	util32.SetTracer(tr32.Record)
+	util32.Keystream(7, 1)
+	if d := DiffTraces(tr, tr32); d != nil {
+		t.Errorf("uint32 trace diverges at %v", d)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTrace(&buf, tr); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadTrace(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := DiffTraces(tr, decoded); d != nil {
+		t.Fatalf("decoded trace diverges at %v", d)
+	}
+
+	decoded[9].State2[3]++
+	decoded[10].Rand[0]++
+	d := DiffTraces(tr, decoded)
+	if d == nil || d.Index != 9 || d.Field != "state2" || d.Element != 3 {
+		t.Errorf("got %v, want event 9 state2[3]", d)
+	}
+
+	if d := DiffTraces(tr, decoded[:5]); d == nil || d.Index != 5 || d.Field != "length" {
+		t.Errorf("got %v, want a length mismatch at event 5", d)
+	}
+}
@@ -0,0 +1,73 @@
+package pasta
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func TestDefaultXOFIsShake128(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	secretKey := randomVector(rng, SecretKeySize, 65537)
+
+	defaultUtil := NewUtil(secretKey, 65537, 3)
+	shakeUtil := NewUtilWithXOF(secretKey, 65537, 3, func() XOF { return sha3.NewShake128() })
+
+	if !equalSlices(defaultUtil.Keystream(DefaultNonce, 7), shakeUtil.Keystream(DefaultNonce, 7)) {
+		t.Errorf("default keystream differs from the SHAKE128 one")
+	}
+}
+
+func TestAlternativeXOFs(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	secretKey := randomVector(rng, SecretKeySize, 65537)
+	plaintext := randomVector(rng, 300, 65537)
+
+	shakeUtil := NewUtil(secretKey, 65537, 3)
+	reference := shakeUtil.Keystream(DefaultNonce, 0)
+	for name, newXOF := range map[string]XOFFactory{"shake256": NewShake256, "aes-ctr": NewAESCTR} {
+		pasta := NewPasta(secretKey, 65537, TestParams)
+		pasta.XOF = newXOF
+
+		decrypted := pasta.Decrypt(pasta.Encrypt(plaintext))
+		if !equalSlices(decrypted, plaintext) {
+			t.Errorf("%s: different plaintexts", name)
+		}
+		if equalSlices(pasta.Keystream(DefaultNonce, 0), reference) {
+			t.Errorf("%s: keystream equals the SHAKE128 one", name)
+		}
+	}
+}
+
+func TestAESCTRDeterministic(t *testing.T) {
+	squeeze := func(seed []byte) []byte {
+		xof := NewAESCTR()
+		xof.Write(seed)
+		out := make([]byte, 40)
+		xof.Read(out[:13])
+		xof.Read(out[13:])
+		return out
+	}
+
+	if !bytes.Equal(squeeze([]byte("seed")), squeeze([]byte("seed"))) {
+		t.Errorf("aes-ctr xof is not deterministic")
+	}
+	if bytes.Equal(squeeze([]byte("seed")), squeeze([]byte("seeds"))) {
+		t.Errorf("aes-ctr xof does not depend on the seed")
+	}
+}
+
+func BenchmarkKeystreamXOF(b *testing.B) {
+	secretKey := randomVector(rand.New(rand.NewSource(3)), SecretKeySize, 65537)
+
+	for name, newXOF := range map[string]XOFFactory{"shake128": NewShake128, "shake256": NewShake256, "aes-ctr": NewAESCTR} {
+		b.Run(name, func(b *testing.B) {
+			util := NewUtilWithXOF(secretKey, 65537, 3, newXOF)
+			for i := 0; i < b.N; i++ {
+				util.Keystream(DefaultNonce, uint64(i))
+			}
+		})
+	}
+}